@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"shop-api/pkg/config"
+)
+
+// runConfigCommand выполняет "shop-api config <команда> [флаги]":
+// print - вывести итоговую конфигурацию (секреты скрыты), validate - только проверить её
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "validate") {
+		fmt.Fprintln(os.Stderr, "usage: shop-api config print|validate [flags]")
+		return 2
+	}
+
+	cfg, err := config.Load(args[1:], os.Stderr)
+	if errors.Is(err, config.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		return 1
+	}
+
+	if args[0] == "validate" {
+		fmt.Println("Configuration is valid")
+		return 0
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
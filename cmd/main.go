@@ -2,19 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	_ "shop-api/docs"
+	"shop-api/docs"
+	"shop-api/internal/auth"
 	"shop-api/internal/cache"
+	"shop-api/internal/consistency"
 	"shop-api/internal/handlers"
+	"shop-api/internal/health"
+	"shop-api/internal/idempotency"
+	"shop-api/internal/logging"
+	"shop-api/internal/metrics"
+	"shop-api/internal/models"
+	"shop-api/internal/ratelimit"
+	"shop-api/internal/reload"
 	"shop-api/internal/repository"
+	"shop-api/internal/requestctx"
+	"shop-api/internal/security"
 	"shop-api/internal/service"
+	"shop-api/internal/tracing"
+	"shop-api/migrations"
 	"shop-api/pkg/config"
 
 	"github.com/go-chi/chi/v5"
@@ -26,80 +40,455 @@ import (
 // @title Shop API
 // @version 1.0
 // @description REST API для интернет-магазина
-// @host 91.105.199.172:8080
 // @BasePath /api
 // @schemes http
 func main() {
-	cfg := config.LoadConfig()
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfigCommand(args[1:]))
+	}
 
-	// Подключение к базе данных
-	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	cfg, err := config.Load(args, os.Stderr)
+	if errors.Is(err, config.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
 
-	poolConfig, err := pgxpool.ParseConfig(connString)
+	// Уровень уже проверен при загрузке конфигурации
+	logLevel, _ := logging.ParseLevel(cfg.Logging.Level)
+	logs, err := logging.New(os.Stdout, cfg.Logging.Format, logLevel)
 	if err != nil {
-		log.Fatalf("Unable to parse database config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Unable to set up logging: %v\n", err)
+		os.Exit(1)
 	}
+	logger := logs.Logger("app")
+	slog.SetDefault(logger)
 
-	db, err := pgxpool.New(context.Background(), poolConfig.ConnString())
+	// Часть конфигурации применяется без перезапуска; компоненты подписываются ниже
+	reloader := reload.New(cfg, args, logs.Logger("config"))
+	reloader.Subscribe(func(old, cfg *config.Config) {
+		if cfg.Logging.Level != old.Logging.Level {
+			level, _ := logging.ParseLevel(cfg.Logging.Level)
+			logs.SetLevel("", level)
+		}
+	})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.SampleRatio)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v\n", err)
+		logger.Error("unable to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Подключение к базе данных
+	db, err := newDBPool(cfg.Database.ConnString())
+	if err != nil {
+		logger.Error("unable to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
+	metrics.RegisterDBPool("primary", db)
+
+	// Реплики для чтения каталога; пока реплика недоступна или отстает, чтение идет с основного сервера
+	replicas := repository.NewReplicas(cfg.Database.ReplicaMaxLag, cfg.Health.CheckTimeout, logs.Logger("db"))
+	for _, addr := range cfg.Database.Replicas {
+		pool, err := newDBPool(cfg.Database.ReplicaConnString(addr))
+		if err != nil {
+			logger.Error("unable to connect to database replica", "replica", addr, "error", err)
+			os.Exit(1)
+		}
+		defer pool.Close()
+		metrics.RegisterDBPool(addr, pool)
+		replicas.Add(addr, pool)
+	}
 
 	// Redis cache
-	redisCache := cache.NewRedisCache("127.0.0.1:6379")
+	redisClient := cache.NewClient(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+	defer redisClient.Close()
+	redisCache := cache.NewRedisCache(redisClient, cfg.Cache.ProductsTTL, cfg.Cache.BreakerThreshold, logs.Logger("cache"))
+	reloader.Subscribe(func(old, cfg *config.Config) {
+		redisCache.SetTTL(cfg.Cache.ProductsTTL)
+	})
 
 	// Инициализация репозитория, сервиса и обработчиков
-	productRepo := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepo, redisCache)
+	transactor := repository.NewTransactor(db)
+	productRepo := repository.NewProductRepository(db, replicas)
+	auditRepo := repository.NewAuditRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	priceRepo := repository.NewPriceRepository(db)
+	pricingService := service.NewPricingService(priceRepo, productRepo, auditRepo, transactor, logs.Logger("pricing"))
+	wishlistRepo := repository.NewWishlistRepository(db)
+	productService := service.NewProductService(productRepo, auditRepo, outboxRepo, transactor, pricingService, redisCache,
+		logs.Logger("products"), service.NewWishlistNotifier(wishlistRepo))
+	auditService := service.NewAuditService(auditRepo)
+	promotionService := service.NewPromotionService(repository.NewPromotionRepository(db), productService, auditRepo, transactor)
+	taxRateRepo := repository.NewTaxRateRepository(db)
+	taxService := service.NewTaxService(taxRateRepo, auditRepo, transactor)
+	taxCalculator := service.NewTableTaxCalculator(taxRateRepo, cfg.Tax.PricesIncludeTax, cfg.Tax.Rounding)
+	shippingRepo := repository.NewShippingRepository(db)
+	shippingService := service.NewShippingService(shippingRepo, productService, auditRepo, transactor,
+		service.NewTableRateProvider(shippingRepo))
+	totalsService := service.NewTotalsService(productService, promotionService, shippingService, taxCalculator)
+	customerRepo := repository.NewCustomerRepository(db)
+	customerService := service.NewCustomerService(customerRepo, auditRepo, transactor)
+	reviewService := service.NewReviewService(repository.NewReviewRepository(db), customerRepo, productService, auditRepo, transactor)
+	wishlistService := service.NewWishlistService(wishlistRepo, customerRepo, productService)
+	relatedService := service.NewRelatedService(repository.NewRelatedRepository(db), productRepo, productService, auditRepo, transactor, logs.Logger("related"))
+
+	var paymentProvider service.PaymentProvider
+	switch cfg.Payments.Provider {
+	case "gateway":
+		paymentProvider = service.NewGatewayPaymentProvider(cfg.Payments.GatewayURL, cfg.Payments.GatewayAPIKey, cfg.Payments.WebhookSecret)
+	default:
+		paymentProvider = service.NewFakePaymentProvider(cfg.Payments.WebhookSecret)
+	}
+	paymentService := service.NewPaymentService(repository.NewPaymentRepository(db), customerRepo, paymentProvider, cfg.Payments.Currency, auditRepo, outboxRepo, transactor)
+
+	// Доменные события публикуются из outbox во внутреннюю шину и внешние приемники
+	eventBus := service.NewEventBus()
+	eventSinks := []service.EventSink{eventBus}
+	for _, name := range cfg.Events.Sinks {
+		switch name {
+		case "redis":
+			eventSinks = append(eventSinks, service.NewRedisStreamSink(redisClient, cfg.Events.RedisStream, logs.Logger("events")))
+		case "nats":
+			natsSink, err := service.NewNATSSink(cfg.Events.NATSURL, cfg.Events.NATSSubjectPrefix, logs.Logger("events"))
+			if err != nil {
+				logger.Error("unable to connect to nats", "error", err)
+				os.Exit(1)
+			}
+			defer natsSink.Close()
+			eventSinks = append(eventSinks, natsSink)
+		}
+	}
+	outboxRelay := service.NewOutboxRelay(outboxRepo, logs.Logger("outbox"), eventSinks...)
+
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(db), auditRepo, transactor, logs.Logger("webhooks"))
+	eventBus.Subscribe(service.AllEvents, webhookService.HandleEvent)
+	eventBus.Subscribe(service.AllEvents, func(ctx context.Context, event *models.Event) error {
+		metrics.DomainEvents.WithLabelValues(event.Type).Inc()
+		return nil
+	})
+
 	productHandler := handlers.NewProductHandler(productService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	priceHandler := handlers.NewPriceHandler(pricingService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	taxHandler := handlers.NewTaxHandler(taxService)
+	cartHandler := handlers.NewCartHandler(totalsService)
+	shippingHandler := handlers.NewShippingHandler(shippingService)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	wishlistHandler := handlers.NewWishlistHandler(wishlistService)
+	relatedHandler := handlers.NewRelatedHandler(relatedService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	logHandler := handlers.NewLogHandler(logs)
+
+	// Проверки готовности: без Postgres сервис не работает, без кэша работает медленнее.
+	// Пока предохранитель кэша разомкнут, проверка Redis не выполняется и кэш считается недоступным.
+	healthChecker := health.New(cfg.Health.CheckTimeout)
+	schemaRepo := repository.NewSchemaRepository(db)
+	healthChecker.Add("postgres", true, schemaRepo.Ping)
+	// Схема не старше миграций, собранных в бинарник; более новая допустима при поэтапном обновлении
+	requiredSchemaVersion := migrations.LatestVersion()
+	healthChecker.Add("migrations", true, func(ctx context.Context) error {
+		applied, err := schemaRepo.AppliedVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if applied < requiredSchemaVersion {
+			return fmt.Errorf("schema version %d, required %d", applied, requiredSchemaVersion)
+		}
+		return nil
+	})
+	healthChecker.Add("redis_cache", false, redisCache.Ping)
+	if replicas.Len() > 0 {
+		healthChecker.Add("postgres_replicas", false, replicas.Ping)
+	}
+
+	var idempotencyStore idempotency.Store
+	switch cfg.Idempotency.Store {
+	case "postgres":
+		idempotencyStore = repository.NewIdempotencyRepository(db)
+	default:
+		redisIdempotencyStore := cache.NewRedisIdempotencyStore(redisClient)
+		// Без хранилища ключей POST-запросы с Idempotency-Key завершаются ошибкой
+		healthChecker.Add("redis_idempotency", true, redisIdempotencyStore.Ping)
+		idempotencyStore = redisIdempotencyStore
+	}
+	authVerifier := auth.NewVerifier(cfg.Auth.JWTSecret, cfg.Auth.Issuer, cfg.Auth.Audience)
+	idempotencyMiddleware := idempotency.New(idempotencyStore, cfg.Idempotency.Retention, logs.Logger("idempotency"))
+
+	// Выключенное ограничение - это пустой список правил, чтобы его можно было включить без перезапуска
+	rateLimitRules := func(cfg *config.Config) ([]ratelimit.Rule, error) {
+		if !cfg.RateLimit.Enabled {
+			return nil, nil
+		}
+		return ratelimit.ParseRules(cfg.RateLimit.Rules)
+	}
+	initialRateLimitRules, err := rateLimitRules(cfg)
+	if err != nil {
+		logger.Error("invalid rate limit rules", "error", err)
+		os.Exit(1)
+	}
+	// Лимиты в памяти используются и как основное хранилище, и как резервное при недоступности Redis
+	memoryRateLimitStore := ratelimit.NewMemoryStore()
+	var rateLimitStore ratelimit.Store = memoryRateLimitStore
+	var redisRateLimitStore *cache.RedisRateLimitStore
+	if cfg.RateLimit.Store == "redis" {
+		redisRateLimitStore = cache.NewRedisRateLimitStore(redisClient, cfg.Cache.BreakerThreshold, logs.Logger("ratelimit"))
+		rateLimitStore = redisRateLimitStore
+	}
+	rateLimiter := ratelimit.New(initialRateLimitRules, cfg.RateLimit.APIKeys, rateLimitStore, memoryRateLimitStore, logs.Logger("ratelimit"))
+	reloader.Check(func(cfg *config.Config) error {
+		_, err := rateLimitRules(cfg)
+		return err
+	})
+	reloader.Subscribe(func(old, cfg *config.Config) {
+		// Правила уже проверены в Check
+		rules, _ := rateLimitRules(cfg)
+		rateLimiter.SetRules(rules)
+		rateLimiter.SetAPIKeys(cfg.RateLimit.APIKeys)
+	})
+
+	// Адрес клиента из заголовков прокси принимается только от доверенных адресов
+	trustedProxies, err := requestctx.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Error("invalid trusted proxies", "error", err)
+		os.Exit(1)
+	}
+
+	// Фоновые задачи останавливаются при завершении сервера
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	go reloader.Run(bgCtx, cfg.Reload.WatchInterval)
+	go replicas.RunHealthCheck(bgCtx, cfg.Database.ReplicaCheckInterval)
+	go redisCache.RunProbe(bgCtx, cfg.Cache.BreakerProbeInterval)
+	if cfg.Features.PriceScheduler {
+		go pricingService.RunScheduler(bgCtx, time.Minute)
+	}
+	if cfg.Features.SimilarityJob {
+		go relatedService.RunSimilarityJob(bgCtx, time.Hour)
+	}
+	go outboxRelay.Run(bgCtx, time.Second)
+	go productService.RunLowStockGauge(bgCtx, 30*time.Second, func() int {
+		return reloader.Config().Metrics.LowStockThreshold
+	})
+	if cfg.Features.WebhookDelivery {
+		go webhookService.RunDeliveryWorker(bgCtx, 5*time.Second)
+	}
+	go idempotencyMiddleware.RunCleanup(bgCtx, time.Hour)
+	go memoryRateLimitStore.RunCleanup(bgCtx, time.Minute)
+	if redisRateLimitStore != nil {
+		go redisRateLimitStore.RunProbe(bgCtx, cfg.Cache.BreakerProbeInterval)
+	}
+
+	cors, err := security.NewCORS(security.CORSOptions{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	if err != nil {
+		logger.Error("invalid CORS configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Создание роутера
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
+	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(requestctx.Middleware(trustedProxies))
+	r.Use(logging.RequestLogger(logs.Logger("http")))
 	r.Use(middleware.Recoverer)
-	r.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
+	r.Use(security.Headers(security.HeadersOptions{
+		HSTSMaxAge:                   cfg.Security.HSTSMaxAge,
+		HSTSIncludeSubdomains:        cfg.Security.HSTSIncludeSubdomains,
+		ReferrerPolicy:               cfg.Security.ReferrerPolicy,
+		ContentSecurityPolicy:        cfg.Security.ContentSecurityPolicy,
+		SwaggerContentSecurityPolicy: cfg.Security.SwaggerContentSecurityPolicy,
+		SwaggerPathPrefix:            "/swagger/",
+	}))
+	r.Use(cors.Handler)
 
-			next.ServeHTTP(w, r)
-		})
-	})
+	// Метрики отдаются отдельным сервером, если задан METRICS_ADDR
+	var metricsServer *http.Server
+	if cfg.Metrics.Addr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:         cfg.Metrics.Addr,
+			Handler:      metricsMux,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+	} else {
+		r.Handle("/metrics", metrics.Handler())
+	}
+
+	r.Get("/healthz", healthChecker.Liveness)
+	r.Get("/readyz", healthChecker.Readiness)
 
-	// Swagger UI
-	r.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://91.105.199.172:8080/swagger/doc.json"),
-	))
+	// Swagger UI; документация запрашивается относительно адреса страницы
+	if cfg.Features.SwaggerUI {
+		docs.SwaggerInfo.Host = cfg.Server.PublicHost
+		r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL("doc.json")))
+	}
 
 	// Регистрация маршрутов
 	r.Route("/api", func(r chi.Router) {
+		// Пользователь нужен ограничению частоты с ключом user, поэтому аутентификация идет первой.
+		// Запрос с неверным токеном отклоняется после лимитера, чтобы попытка была учтена.
+		r.Use(auth.Authenticate(authVerifier))
+		r.Use(rateLimiter.Handler)
+		r.Use(auth.RejectFailed)
+		r.Use(customerHandler.RejectBlocked)
+		if replicas.Len() > 0 {
+			r.Use(consistency.ReadYourWrites(cfg.Database.ReadYourWritesWindow))
+		}
+		r.Use(idempotencyMiddleware.Handler)
+
 		r.Route("/products", func(r chi.Router) {
 			r.Get("/", productHandler.GetProducts)
-			r.Post("/", productHandler.CreateProduct)
 			r.Get("/{id}", productHandler.GetProduct)
-			r.Put("/{id}", productHandler.UpdateProduct)
-			r.Delete("/{id}", productHandler.DeleteProduct)
+			r.Get("/{id}/price-history", priceHandler.GetPriceHistory)
+			r.Get("/{id}/reviews", reviewHandler.GetProductReviews)
+			r.With(auth.Required).Post("/{id}/reviews", reviewHandler.CreateReview)
+			r.Get("/{id}/related", relatedHandler.GetRelatedProducts)
+
+			// Каталог меняют только администраторы, чтобы в журнале изменений был автор
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireRole(auth.RoleAdmin))
+				r.Post("/", productHandler.CreateProduct)
+				r.Put("/{id}", productHandler.UpdateProduct)
+				r.Delete("/{id}", productHandler.DeleteProduct)
+				r.Get("/{id}/scheduled-prices", priceHandler.GetScheduledPrices)
+				r.Post("/{id}/scheduled-prices", priceHandler.CreateScheduledPrice)
+				r.Delete("/{id}/scheduled-prices/{scheduleId}", priceHandler.DeleteScheduledPrice)
+			})
+		})
+
+		r.Post("/promotions/evaluate", promotionHandler.EvaluateCart)
+		r.Post("/cart/totals", cartHandler.GetCartTotals)
+		r.Post("/shipping/rates", shippingHandler.GetShippingRates)
+		r.With(auth.Required).Post("/payments", paymentHandler.CreatePayment)
+		r.Post("/payments/webhook", paymentHandler.PaymentWebhook)
+		r.With(auth.Required).Post("/reviews/{id}/helpful", reviewHandler.VoteReviewHelpful)
+
+		r.Route("/wishlists", func(r chi.Router) {
+			r.Get("/shared/{token}", wishlistHandler.GetSharedWishlist)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.Required)
+				r.Get("/", wishlistHandler.GetWishlists)
+				r.Post("/", wishlistHandler.CreateWishlist)
+				r.Get("/notifications", wishlistHandler.GetWishlistNotifications)
+				r.Post("/notifications/{id}/read", wishlistHandler.MarkWishlistNotificationRead)
+				r.Get("/{id}", wishlistHandler.GetWishlist)
+				r.Put("/{id}", wishlistHandler.RenameWishlist)
+				r.Delete("/{id}", wishlistHandler.DeleteWishlist)
+				r.Post("/{id}/items", wishlistHandler.AddWishlistItem)
+				r.Delete("/{id}/items/{productId}", wishlistHandler.RemoveWishlistItem)
+				r.Post("/{id}/share", wishlistHandler.ShareWishlist)
+				r.Delete("/{id}/share", wishlistHandler.UnshareWishlist)
+			})
+		})
+
+		r.Route("/me", func(r chi.Router) {
+			r.Use(auth.Required)
+			r.Get("/", customerHandler.GetProfile)
+			r.Put("/", customerHandler.UpdateProfile)
+			r.Get("/addresses", customerHandler.GetAddresses)
+			r.Post("/addresses", customerHandler.CreateAddress)
+			r.Put("/addresses/{id}", customerHandler.UpdateAddress)
+			r.Delete("/addresses/{id}", customerHandler.DeleteAddress)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(auth.RequireRole(auth.RoleAdmin))
+			r.Get("/audit", auditHandler.GetAuditLog)
+			r.Get("/log-levels", logHandler.GetLogLevels)
+			r.Put("/log-levels", logHandler.SetLogLevel)
+
+			r.Route("/promotions", func(r chi.Router) {
+				r.Get("/", promotionHandler.GetPromotions)
+				r.Post("/", promotionHandler.CreatePromotion)
+				r.Get("/{id}", promotionHandler.GetPromotion)
+				r.Put("/{id}", promotionHandler.UpdatePromotion)
+				r.Delete("/{id}", promotionHandler.DeletePromotion)
+			})
+
+			r.Route("/tax-rates", func(r chi.Router) {
+				r.Get("/", taxHandler.GetTaxRates)
+				r.Post("/", taxHandler.CreateTaxRate)
+				r.Put("/{id}", taxHandler.UpdateTaxRate)
+				r.Delete("/{id}", taxHandler.DeleteTaxRate)
+			})
+
+			r.Route("/shipping-zones", func(r chi.Router) {
+				r.Get("/", shippingHandler.GetShippingZones)
+				r.Post("/", shippingHandler.CreateShippingZone)
+				r.Put("/{id}", shippingHandler.UpdateShippingZone)
+				r.Delete("/{id}", shippingHandler.DeleteShippingZone)
+			})
+
+			r.Route("/customers", func(r chi.Router) {
+				r.Get("/", customerHandler.GetCustomers)
+				r.Get("/{id}", customerHandler.GetCustomer)
+				r.Post("/{id}/block", customerHandler.BlockCustomer)
+				r.Post("/{id}/unblock", customerHandler.UnblockCustomer)
+			})
+
+			r.Route("/products/{id}/relations", func(r chi.Router) {
+				r.Get("/", relatedHandler.GetProductRelations)
+				r.Post("/", relatedHandler.CreateProductRelation)
+				r.Delete("/{type}/{relatedId}", relatedHandler.DeleteProductRelation)
+			})
+
+			r.Route("/reviews", func(r chi.Router) {
+				r.Get("/", reviewHandler.GetReviews)
+				r.Post("/{id}/approve", reviewHandler.ApproveReview)
+				r.Post("/{id}/reject", reviewHandler.RejectReview)
+				r.Put("/{id}/reply", reviewHandler.ReplyToReview)
+				r.Delete("/{id}", reviewHandler.DeleteReview)
+			})
+
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Get("/", webhookHandler.GetWebhookSubscriptions)
+				r.Post("/", webhookHandler.CreateWebhookSubscription)
+				r.Get("/{id}", webhookHandler.GetWebhookSubscription)
+				r.Put("/{id}", webhookHandler.UpdateWebhookSubscription)
+				r.Delete("/{id}", webhookHandler.DeleteWebhookSubscription)
+				r.Get("/{id}/deliveries", webhookHandler.GetWebhookDeliveries)
+				r.Get("/deliveries/{deliveryId}", webhookHandler.GetWebhookDelivery)
+				r.Post("/deliveries/{deliveryId}/redeliver", webhookHandler.RedeliverWebhook)
+			})
+
+			r.Route("/payments", func(r chi.Router) {
+				r.Get("/{id}", paymentHandler.GetPayment)
+				r.Post("/{id}/capture", paymentHandler.CapturePayment)
+				r.Post("/{id}/void", paymentHandler.VoidPayment)
+				r.Post("/{id}/refund", paymentHandler.RefundPayment)
+			})
 		})
 	})
 
 	// Запуск сервера
 	server := &http.Server{
-		Addr:         "0.0.0.0:8080",
+		Addr:         cfg.Server.Addr(),
 		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Graceful shutdown
@@ -108,21 +497,53 @@ func main() {
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v\n", err)
+			logger.Error("server error", "error", err)
+			os.Exit(1)
 		}
 	}()
 
-	log.Printf("Server started on port %s\n", "8080")
+	if metricsServer != nil {
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+		logger.Info("metrics server started", "addr", cfg.Metrics.Addr)
+	}
+
+	logger.Info("server started", "addr", server.Addr)
 
 	<-done
-	log.Println("Server is shutting down...")
+	logger.Info("server is shutting down")
+	// Балансировщик перестает направлять трафик, пока текущие запросы дообрабатываются
+	healthChecker.SetShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDrainDelay)
+	stopBackground()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v\n", err)
+		logger.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
+	}
+	if metricsServer != nil {
+		metricsServer.Shutdown(ctx)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("error flushing traces", "error", err)
 	}
 
-	log.Println("Server exited properly")
+	logger.Info("server exited properly")
+}
+
+// newDBPool подключается к Postgres с трассировкой запросов
+func newDBPool(connString string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	return pgxpool.NewWithConfig(context.Background(), poolConfig)
 }
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RoleAdmin - роль администратора магазина
+const RoleAdmin = "admin"
+
+// Identity описывает аутентифицированного пользователя текущего запроса
+type Identity struct {
+	UserID int64
+	Email  string
+	Role   string
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// Actor возвращает строковый идентификатор пользователя для журналов
+func Actor(ctx context.Context) string {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if identity.Email != "" {
+		return identity.Email
+	}
+	return "user:" + strconv.FormatInt(identity.UserID, 10)
+}
+
+// Principal возвращает устойчивый идентификатор пользователя для разделения его данных
+// (ключей идемпотентности и т.п.); в отличие от Actor не зависит от email
+func Principal(ctx context.Context) (string, bool) {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return "user:" + strconv.FormatInt(identity.UserID, 10), true
+}
+
+// Authenticate проверяет токен из заголовка Authorization: Bearer и сохраняет пользователя
+// в контексте. Запрос без заголовка обрабатывается как анонимный. Запрос с неверным токеном
+// только помечается, а отклоняет его RejectFailed: между ними ограничение частоты учитывает
+// неудачные попытки, чтобы перебор токенов упирался в лимит.
+func Authenticate(verifier *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), failureKey{}, "invalid_request")))
+				return
+			}
+			identity, err := verifier.Verify(token)
+			if err != nil {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), failureKey{}, "invalid_token")))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+type failureKey struct{}
+
+// Failed сообщает, что запрос пришел с неверными учетными данными
+func Failed(ctx context.Context) bool {
+	_, failed := ctx.Value(failureKey{}).(string)
+	return failed
+}
+
+// RejectFailed отклоняет запросы, помеченные Authenticate как неудачная аутентификация
+func RejectFailed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code, failed := r.Context().Value(failureKey{}).(string); failed {
+			unauthorized(w, code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole пропускает только пользователей с ролью role
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := IdentityFromContext(r.Context())
+			if !ok {
+				unauthorized(w, "")
+				return
+			}
+			if identity.Role != role {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, code string) {
+	challenge := "Bearer"
+	if code != "" {
+		challenge += ` error="` + code + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// Required отклоняет запросы без аутентифицированного пользователя
+func Required(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := IdentityFromContext(r.Context()); !ok {
+			unauthorized(w, "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	verifier := NewVerifier(testSecret, "", "")
+	token := func(role string) string {
+		return "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{
+			"sub":  "42",
+			"role": role,
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	chain := func(guard func(http.Handler) http.Handler) http.Handler {
+		return Authenticate(verifier)(RejectFailed(guard(ok)))
+	}
+
+	tests := []struct {
+		name          string
+		guard         func(http.Handler) http.Handler
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "required without token", guard: Required, wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "required with token", guard: Required, authorization: token("customer"), wantStatus: http.StatusNoContent},
+		{name: "required with invalid token", guard: Required, authorization: "Bearer garbage", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_token"`},
+		{name: "basic scheme", guard: Required, authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_request"`},
+		{name: "admin without token", guard: RequireRole(RoleAdmin), wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "admin with customer token", guard: RequireRole(RoleAdmin), authorization: token("customer"), wantStatus: http.StatusForbidden},
+		{name: "admin with admin token", guard: RequireRole(RoleAdmin), authorization: token(RoleAdmin), wantStatus: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			chain(tt.guard).ServeHTTP(rec, r)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
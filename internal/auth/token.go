@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier проверяет токены доступа (JWT, HS256), которые выдает сервис входа.
+// Идентификатор пользователя передается в sub, роль - в role.
+type Verifier struct {
+	secret []byte
+	parser *jwt.Parser
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// NewVerifier создает проверку токенов; пустые issuer и audience не проверяются
+func NewVerifier(secret, issuer, audience string) *Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		// Допуск на расхождение часов с сервисом входа
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &Verifier{secret: []byte(secret), parser: jwt.NewParser(opts...)}
+}
+
+func (v *Verifier) Verify(token string) (*Identity, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return v.secret, nil
+	})
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return nil, ErrInvalidToken
+	}
+	return &Identity{UserID: userID, Email: c.Email, Role: c.Role}, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func sign(t *testing.T, method jwt.SigningMethod, secret string, c jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, c).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifierVerify(t *testing.T) {
+	verifier := NewVerifier(testSecret, "login", "shop-api")
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "42",
+			"email": "admin@example.com",
+			"role":  RoleAdmin,
+			"iss":   "login",
+			"aud":   "shop-api",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		c := valid()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  *Identity
+	}{
+		{"valid", sign(t, jwt.SigningMethodHS256, testSecret, valid()), &Identity{UserID: 42, Email: "admin@example.com", Role: RoleAdmin}},
+		{"wrong secret", sign(t, jwt.SigningMethodHS256, "another-secret-another-secret-xx", valid()), nil},
+		{"other algorithm", sign(t, jwt.SigningMethodHS512, testSecret, valid()), nil},
+		{"expired", sign(t, jwt.SigningMethodHS256, testSecret, with("exp", time.Now().Add(-time.Hour).Unix())), nil},
+		{"no expiration", sign(t, jwt.SigningMethodHS256, testSecret, with("exp", nil)), nil},
+		{"wrong issuer", sign(t, jwt.SigningMethodHS256, testSecret, with("iss", "other")), nil},
+		{"wrong audience", sign(t, jwt.SigningMethodHS256, testSecret, with("aud", "other")), nil},
+		{"non-numeric subject", sign(t, jwt.SigningMethodHS256, testSecret, with("sub", "admin")), nil},
+		{"garbage", "not.a.token", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("Verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
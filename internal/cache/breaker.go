@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"shop-api/internal/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCircuitOpen возвращается без обращения к Redis, пока цепь разомкнута
+var ErrCircuitOpen = errors.New("redis circuit breaker is open")
+
+// Breaker размыкает цепь после threshold ошибок Redis подряд. Пока цепь разомкнута,
+// обращения к Redis не выполняются, а фоновая проверка пытается её замкнуть.
+type Breaker struct {
+	name      string
+	threshold int
+	logger    *slog.Logger
+
+	open     atomic.Bool
+	mu       sync.Mutex
+	failures int
+}
+
+func NewBreaker(name string, threshold int, logger *slog.Logger) *Breaker {
+	b := &Breaker{name: name, threshold: threshold, logger: logger}
+	metrics.CircuitOpen.WithLabelValues(name).Set(0)
+	return b
+}
+
+// Allow сообщает, можно ли обращаться к Redis
+func (b *Breaker) Allow() bool {
+	return !b.open.Load()
+}
+
+// Record учитывает результат обращения. Промах кэша и отмена запроса клиентом не считаются ошибкой.
+func (b *Breaker) Record(ctx context.Context, err error) {
+	if err != nil && (errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled)) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold && !b.open.Load() {
+		b.trip(ctx, err)
+	}
+}
+
+// Trip размыкает цепь сразу, например если Redis недоступен при запуске
+func (b *Breaker) Trip(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open.Load() {
+		b.trip(ctx, err)
+	}
+}
+
+func (b *Breaker) trip(ctx context.Context, err error) {
+	b.open.Store(true)
+	metrics.CircuitOpen.WithLabelValues(b.name).Set(1)
+	metrics.CircuitTransitions.WithLabelValues(b.name, "open").Inc()
+	b.logger.WarnContext(ctx, "circuit breaker opened", "breaker", b.name, "failures", b.failures, "error", err)
+}
+
+// RunProbe раз в interval проверяет Redis, пока цепь разомкнута, и замыкает её после успешной
+// проверки. Запросы пользователей в это время к Redis не обращаются.
+func (b *Breaker) RunProbe(ctx context.Context, interval time.Duration, probe func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if b.Allow() {
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, interval)
+		err := probe(probeCtx)
+		cancel()
+		if err != nil {
+			b.logger.DebugContext(ctx, "circuit breaker probe failed", "breaker", b.name, "error", err)
+			continue
+		}
+
+		b.mu.Lock()
+		b.failures = 0
+		b.open.Store(false)
+		b.mu.Unlock()
+		metrics.CircuitOpen.WithLabelValues(b.name).Set(0)
+		metrics.CircuitTransitions.WithLabelValues(b.name, "closed").Inc()
+		b.logger.InfoContext(ctx, "circuit breaker closed", "breaker", b.name)
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"shop-api/internal/models"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const idempotencyKeyPrefix = "idempotency:"
+
+// RedisIdempotencyStore хранит ответы на запросы с ключом идемпотентности в Redis.
+// Срок хранения задается TTL ключа, поэтому отдельная очистка не нужна.
+type RedisIdempotencyStore struct {
+	client *redis.Client
+}
+
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+func (s *RedisIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*models.IdempotencyRecord, bool, error) {
+	record := &models.IdempotencyRecord{Fingerprint: fingerprint}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.client.SetNX(ctx, idempotencyKeyPrefix+key, data, lockTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		return record, true, nil
+	}
+
+	data, err = s.client.Get(ctx, idempotencyKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Ключ истек между SETNX и GET, клиент может повторить запрос
+		return record, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing models.IdempotencyRecord
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, record *models.IdempotencyRecord, retention time.Duration) error {
+	record.Completed = true
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, idempotencyKeyPrefix+key, data, retention).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, idempotencyKeyPrefix+key).Err()
+}
+
+func (s *RedisIdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (s *RedisIdempotencyStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"shop-api/internal/ratelimit"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Корзина токенов: пополняется по времени сервера Redis, чтобы расхождение часов
+// между экземплярами API не влияло на лимит. Возвращает {разрешено, остаток токенов}.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimitStore хранит корзины в Redis, чтобы лимит был общим для всех экземпляров.
+// Пока Redis недоступен, предохранитель сразу возвращает ErrCircuitOpen.
+type RedisRateLimitStore struct {
+	client  *redis.Client
+	breaker *Breaker
+}
+
+func NewRedisRateLimitStore(client *redis.Client, failureThreshold int, logger *slog.Logger) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client:  client,
+		breaker: NewBreaker("redis_ratelimit", failureThreshold, logger),
+	}
+}
+
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (*ratelimit.Result, error) {
+	if !s.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	// Токенов в миллисекунду
+	rate := float64(limit.Limit) / float64(limit.Period.Milliseconds())
+	values, err := takeTokenScript.Run(ctx, s.client, []string{key}, rate, limit.Burst).Slice()
+	s.breaker.Record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	return ratelimit.NewResult(limit, tokens, allowed == 1), nil
+}
+
+// RunProbe замыкает предохранитель, когда Redis снова доступен
+func (s *RedisRateLimitStore) RunProbe(ctx context.Context, interval time.Duration) {
+	s.breaker.RunProbe(ctx, interval, func(ctx context.Context) error {
+		return s.client.Ping(ctx).Err()
+	})
+}
@@ -3,85 +3,154 @@ package cache
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"shop-api/internal/metrics"
 	"shop-api/internal/models"
+	"shop-api/internal/tracing"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-type RedisCache struct {
-	client *redis.Client
-}
+const productsKey = "products"
 
-func NewRedisCache(addr string) *RedisCache {
-	log.Printf("Initializing Redis cache with address: %s", addr)
+// NewClient создает клиент Redis с трассировкой и метриками команд; клиент общий для всех хранилищ
+func NewClient(addr, password string, db int) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
-		Password: "", // без пароля
-		DB:       0,
+		Password: password,
+		DB:       db,
 	})
+	client.AddHook(tracing.RedisHook{})
+	client.AddHook(metrics.RedisHook{})
+	return client
+}
+
+type RedisCache struct {
+	client  *redis.Client
+	ttl     atomic.Int64
+	breaker *Breaker
+	logger  *slog.Logger
+}
 
-	// Проверяем подключение
+// NewRedisCache создает кэш с предохранителем, который размыкается после failureThreshold ошибок подряд.
+// Пока он разомкнут, кэш пропускается без обращения к Redis.
+func NewRedisCache(client *redis.Client, ttl time.Duration, failureThreshold int, logger *slog.Logger) *RedisCache {
+	logger.Info("initializing redis cache", "addr", client.Options().Addr)
+	c := &RedisCache{
+		client:  client,
+		breaker: NewBreaker("redis_cache", failureThreshold, logger),
+		logger:  logger,
+	}
+
+	c.SetTTL(ttl)
+
+	// Проверяем подключение; без Redis сервис стартует с разомкнутой цепью
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
-		log.Printf("Failed to connect to Redis: %v", err)
+		logger.Error("failed to connect to redis", "error", err)
+		c.breaker.Trip(ctx, err)
 	} else {
-		log.Printf("Successfully connected to Redis")
+		logger.Info("connected to redis")
 	}
 
-	return &RedisCache{
-		client: client,
-	}
+	return c
+}
+
+// SetTTL меняет время жизни кэша; действует для следующих записей
+func (r *RedisCache) SetTTL(ttl time.Duration) {
+	r.ttl.Store(int64(ttl))
+}
+
+// Available сообщает, обращается ли кэш к Redis
+func (r *RedisCache) Available() bool {
+	return r.breaker.Allow()
+}
+
+// RunProbe проверяет Redis, пока предохранитель разомкнут. Перед замыканием кэш каталога
+// очищается, так как изменения за время недоступности в него не попали.
+func (r *RedisCache) RunProbe(ctx context.Context, interval time.Duration) {
+	r.breaker.RunProbe(ctx, interval, func(ctx context.Context) error {
+		if err := r.client.Ping(ctx).Err(); err != nil {
+			return err
+		}
+		return r.client.Del(ctx, productsKey).Err()
+	})
 }
 
 func (r *RedisCache) GetProducts(ctx context.Context) ([]*models.Product, error) {
+	if !r.breaker.Allow() {
+		metrics.CacheRequests.WithLabelValues("products", "bypass").Inc()
+		return nil, ErrCircuitOpen
+	}
 	start := time.Now()
-	log.Printf("Redis: Trying to get products from cache")
 
-	data, err := r.client.Get(ctx, "products").Bytes()
+	data, err := r.client.Get(ctx, productsKey).Bytes()
+	r.breaker.Record(ctx, err)
 	if err != nil {
-		log.Printf("Redis: Cache miss for products (took %v)", time.Since(start))
+		if errors.Is(err, redis.Nil) {
+			r.logger.DebugContext(ctx, "products cache miss", "duration", time.Since(start))
+		} else {
+			r.logger.WarnContext(ctx, "error getting products from cache", "error", err, "duration", time.Since(start))
+		}
+		metrics.CacheRequests.WithLabelValues("products", "miss").Inc()
 		return nil, err
 	}
 
 	var products []*models.Product
 	if err := json.Unmarshal(data, &products); err != nil {
-		log.Printf("Redis: Error unmarshaling products: %v", err)
+		r.logger.ErrorContext(ctx, "error unmarshaling cached products", "error", err)
 		return nil, err
 	}
 
-	log.Printf("Redis: Cache hit for products, found %d items (took %v)", len(products), time.Since(start))
+	metrics.CacheRequests.WithLabelValues("products", "hit").Inc()
+	r.logger.DebugContext(ctx, "products cache hit", "count", len(products), "duration", time.Since(start))
 	return products, nil
 }
 
 func (r *RedisCache) SetProducts(ctx context.Context, products []*models.Product) error {
+	if !r.breaker.Allow() {
+		return ErrCircuitOpen
+	}
 	start := time.Now()
-	log.Printf("Redis: Setting %d products to cache", len(products))
 
 	data, err := json.Marshal(products)
 	if err != nil {
-		log.Printf("Redis: Error marshaling products: %v", err)
+		r.logger.ErrorContext(ctx, "error marshaling products", "error", err)
 		return err
 	}
 
-	err = r.client.Set(ctx, "products", data, 5*time.Minute).Err()
+	err = r.client.Set(ctx, productsKey, data, time.Duration(r.ttl.Load())).Err()
+	r.breaker.Record(ctx, err)
 	if err != nil {
-		log.Printf("Redis: Error setting products to cache: %v (took %v)", err, time.Since(start))
+		r.logger.WarnContext(ctx, "error caching products", "error", err, "duration", time.Since(start))
 		return err
 	}
 
-	log.Printf("Redis: Successfully cached %d products (took %v)", len(products), time.Since(start))
+	r.logger.DebugContext(ctx, "products cached", "count", len(products), "duration", time.Since(start))
 	return nil
 }
 
 func (c *RedisCache) InvalidateProducts(ctx context.Context) error {
-	log.Printf("Invalidating products cache")
-	err := c.client.Del(ctx, "products").Err()
+	if !c.breaker.Allow() {
+		return ErrCircuitOpen
+	}
+	err := c.client.Del(ctx, productsKey).Err()
+	c.breaker.Record(ctx, err)
 	if err != nil {
-		log.Printf("Error invalidating products cache: %v", err)
+		c.logger.WarnContext(ctx, "error invalidating products cache", "error", err)
 		return err
 	}
-	log.Printf("Successfully invalidated products cache")
+	c.logger.DebugContext(ctx, "products cache invalidated")
 	return nil
 }
+
+// Ping проверяет Redis; при разомкнутом предохранителе сразу возвращает ErrCircuitOpen
+func (r *RedisCache) Ping(ctx context.Context) error {
+	if !r.breaker.Allow() {
+		return ErrCircuitOpen
+	}
+	return r.client.Ping(ctx).Err()
+}
@@ -0,0 +1,61 @@
+package consistency
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shop-api/internal/repository"
+)
+
+// Cookie хранит время (unix), до которого клиент читает с основного сервера
+const Cookie = "shop_primary_until"
+
+// ReadYourWrites направляет чтения на основной сервер, чтобы клиент видел свои изменения,
+// пока реплики их не получили. Изменяющий запрос целиком выполняется на основном сервере
+// и помечает клиента cookie на window; следующие запросы с этой cookie тоже читают с основного.
+// Cookie ставится до обработки, поэтому неудачный запрос тоже помечает клиента - это безопасно.
+func ReadYourWrites(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			switch {
+			case isMutation(r.Method):
+				ctx = repository.WithPrimary(ctx)
+				if window > 0 {
+					until := time.Now().Add(window)
+					http.SetCookie(w, &http.Cookie{
+						Name:     Cookie,
+						Value:    strconv.FormatInt(until.Unix(), 10),
+						Path:     "/",
+						MaxAge:   int(math.Ceil(window.Seconds())),
+						HttpOnly: true,
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+			case recentlyWrote(r):
+				ctx = repository.WithPrimary(ctx)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// recentlyWrote проверяет срок в cookie: клиент мог не удалить её вовремя
+func recentlyWrote(r *http.Request) bool {
+	cookie, err := r.Cookie(Cookie)
+	if err != nil {
+		return false
+	}
+	until, err := strconv.ParseInt(cookie.Value, 10, 64)
+	return err == nil && time.Now().Unix() <= until
+}
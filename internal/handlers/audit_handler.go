@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shop-api/internal/models"
+	"shop-api/internal/service"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+func NewAuditHandler(service *service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAuditLog godoc
+// @Summary Журнал аудита
+// @Description Возвращает записи журнала изменений каталога с фильтрацией по сущности, автору и периоду
+// @Tags admin
+// @Produce json
+// @Param entity query string false "Тип сущности (например, product)"
+// @Param entity_id query int false "ID сущности"
+// @Param actor query string false "Автор изменения"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Количество записей (по умолчанию 50)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /admin/audit [get]
+func (h *AuditHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity"),
+		Actor:      query.Get("actor"),
+	}
+
+	var err error
+	if v := query.Get("entity_id"); v != "" {
+		if filter.EntityID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, "Invalid entity_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "Invalid from", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to get audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
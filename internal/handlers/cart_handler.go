@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+)
+
+type CartHandler struct {
+	totals *service.TotalsService
+}
+
+func NewCartHandler(totals *service.TotalsService) *CartHandler {
+	return &CartHandler{totals: totals}
+}
+
+// GetCartTotals godoc
+// @Summary Рассчитать итог корзины
+// @Description Применяет акции и рассчитывает налоги для адреса доставки
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param cart body models.CartTotalsRequest true "Позиции корзины, промокоды и адрес доставки"
+// @Success 200 {object} models.CartTotals
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /cart/totals [post]
+func (h *CartHandler) GetCartTotals(w http.ResponseWriter, r *http.Request) {
+	var req models.CartTotalsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	totals, err := h.totals.CartTotals(r.Context(), &req)
+	if err != nil {
+		writeCartError(w, err, "Failed to calculate cart totals")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(totals)
+}
+
+func writeCartError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCart):
+		http.Error(w, "Invalid cart", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrProductNotFound):
+		http.Error(w, "Product not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrShippingMethodUnavailable):
+		http.Error(w, "Shipping method is not available", http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
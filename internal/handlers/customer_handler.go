@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CustomerHandler struct {
+	service *service.CustomerService
+}
+
+func NewCustomerHandler(service *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// RejectBlocked отклоняет запросы заблокированных клиентов с 403. Ставится после аутентификации
+func (h *CustomerHandler) RejectBlocked(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h.service.CheckNotBlocked(r.Context()); err != nil {
+			writeCustomerError(w, err, "Failed to check customer")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetProfile godoc
+// @Summary Профиль текущего пользователя
+// @Tags me
+// @Produce json
+// @Success 200 {object} models.Customer
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /me [get]
+func (h *CustomerHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	customer, err := h.service.GetProfile(r.Context())
+	if err != nil {
+		writeCustomerError(w, err, "Failed to get profile")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// UpdateProfile godoc
+// @Summary Обновить профиль текущего пользователя
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param profile body models.UpdateProfileRequest true "Данные профиля"
+// @Success 200 {object} models.Customer
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /me [put]
+func (h *CustomerHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.service.UpdateProfile(r.Context(), &req)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to update profile")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// GetAddresses godoc
+// @Summary Адреса текущего пользователя
+// @Tags me
+// @Produce json
+// @Success 200 {array} models.Address
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /me/addresses [get]
+func (h *CustomerHandler) GetAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.service.GetAddresses(r.Context())
+	if err != nil {
+		writeCustomerError(w, err, "Failed to get addresses")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(addresses)
+}
+
+// CreateAddress godoc
+// @Summary Добавить адрес
+// @Description Индекс проверяется по формату страны. Первый адрес типа становится адресом по умолчанию.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param address body models.AddressRequest true "Адрес"
+// @Success 201 {object} models.Address
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 500 {string} string
+// @Router /me/addresses [post]
+func (h *CustomerHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	var req models.AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	address, err := h.service.CreateAddress(r.Context(), &req)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to create address")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(address)
+}
+
+// UpdateAddress godoc
+// @Summary Обновить адрес
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param id path int true "ID адреса"
+// @Param address body models.AddressRequest true "Адрес"
+// @Success 200 {object} models.Address
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /me/addresses/{id} [put]
+func (h *CustomerHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	address, err := h.service.UpdateAddress(r.Context(), id, &req)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to update address")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(address)
+}
+
+// DeleteAddress godoc
+// @Summary Удалить адрес
+// @Tags me
+// @Param id path int true "ID адреса"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /me/addresses/{id} [delete]
+func (h *CustomerHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteAddress(r.Context(), id); err != nil {
+		writeCustomerError(w, err, "Failed to delete address")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCustomers godoc
+// @Summary Список клиентов
+// @Description Поиск по email, имени и телефону с постраничным выводом
+// @Tags admin
+// @Produce json
+// @Param q query string false "Строка поиска"
+// @Param blocked query bool false "Только заблокированные / незаблокированные"
+// @Param limit query int false "Количество записей (по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.CustomerPage
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /admin/customers [get]
+func (h *CustomerHandler) GetCustomers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.CustomerFilter{Query: query.Get("q")}
+
+	var err error
+	if v := query.Get("blocked"); v != "" {
+		blocked, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid blocked", http.StatusBadRequest)
+			return
+		}
+		filter.Blocked = &blocked
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.service.ListCustomers(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to get customers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// GetCustomer godoc
+// @Summary Получить клиента по ID
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Success 200 {object} models.Customer
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.service.GetCustomer(r.Context(), id)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to get customer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// BlockCustomer godoc
+// @Summary Заблокировать клиента
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Success 200 {object} models.Customer
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/customers/{id}/block [post]
+func (h *CustomerHandler) BlockCustomer(w http.ResponseWriter, r *http.Request) {
+	h.setBlocked(w, r, true)
+}
+
+// UnblockCustomer godoc
+// @Summary Разблокировать клиента
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Success 200 {object} models.Customer
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/customers/{id}/unblock [post]
+func (h *CustomerHandler) UnblockCustomer(w http.ResponseWriter, r *http.Request) {
+	h.setBlocked(w, r, false)
+}
+
+func (h *CustomerHandler) setBlocked(w http.ResponseWriter, r *http.Request, blocked bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.service.SetBlocked(r.Context(), id, blocked)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to update customer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+func writeCustomerError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrCustomerBlocked):
+		http.Error(w, "Customer is blocked", http.StatusForbidden)
+	case errors.Is(err, service.ErrInvalidAddress):
+		http.Error(w, "Invalid address", http.StatusBadRequest)
+	case errors.Is(err, service.ErrInvalidPostalCode):
+		http.Error(w, "Invalid postal code for country", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrCustomerNotFound):
+		http.Error(w, "Customer not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrAddressNotFound):
+		http.Error(w, "Address not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrCustomerExists):
+		http.Error(w, "Customer with this email already exists", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"shop-api/internal/logging"
+	"shop-api/internal/models"
+)
+
+type LogHandler struct {
+	logs *logging.Manager
+}
+
+func NewLogHandler(logs *logging.Manager) *LogHandler {
+	return &LogHandler{logs: logs}
+}
+
+// GetLogLevels godoc
+// @Summary Уровни логирования
+// @Description Уровень по умолчанию и уровни компонентов
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.LogLevels
+// @Router /admin/log-levels [get]
+func (h *LogHandler) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	defaultLevel, components := h.logs.Levels()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LogLevels{Default: defaultLevel, Components: components})
+}
+
+// SetLogLevel godoc
+// @Summary Изменить уровень логирования
+// @Description Без компонента меняется уровень по умолчанию для всех компонентов, кроме заданных явно. Изменение действует до перезапуска.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param level body models.LogLevelRequest true "Уровень: debug, info, warn или error"
+// @Success 200 {object} models.LogLevels
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Router /admin/log-levels [put]
+func (h *LogHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req models.LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, "Invalid log level", http.StatusBadRequest)
+		return
+	}
+	if !h.logs.SetLevel(req.Component, level) {
+		http.Error(w, "Log component not found", http.StatusNotFound)
+		return
+	}
+
+	h.GetLogLevels(w, r)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxWebhookBodySize ограничивает размер тела вебхука
+const maxWebhookBodySize = 1 << 20
+
+type PaymentHandler struct {
+	service *service.PaymentService
+}
+
+func NewPaymentHandler(service *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// CreatePayment godoc
+// @Summary Создать платеж
+// @Description Создает и авторизует платеж. Повтор запроса с тем же Idempotency-Key возвращает исходный платеж без повторного списания
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string true "Ключ идемпотентности"
+// @Param payment body models.CreatePaymentRequest true "Параметры платежа"
+// @Success 201 {object} models.Payment
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 402 {string} string
+// @Failure 403 {string} string
+// @Failure 422 {string} string
+// @Failure 502 {string} string
+// @Failure 500 {string} string
+// @Router /payments [post]
+func (h *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.service.CreatePayment(r.Context(), r.Header.Get("Idempotency-Key"), &req)
+	if err != nil {
+		writePaymentError(w, err, "Failed to create payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// PaymentWebhook godoc
+// @Summary Вебхук платежного провайдера
+// @Description Принимает подписанные события провайдера (заголовки X-Webhook-Timestamp и X-Webhook-Signature). Повторная доставка события игнорируется
+// @Tags payments
+// @Accept json
+// @Success 200 "OK"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /payments/webhook [post]
+func (h *PaymentHandler) PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.HandleWebhook(r.Context(), r.Header, body); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhookSignature):
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		case errors.Is(err, service.ErrInvalidWebhookPayload):
+			http.Error(w, "Invalid webhook payload", http.StatusBadRequest)
+		default:
+			writePaymentError(w, err, "Failed to process webhook")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetPayment godoc
+// @Summary Получить платеж
+// @Description Возвращает платеж вместе с историей обращений к провайдеру
+// @Tags payments
+// @Produce json
+// @Param id path int true "ID платежа"
+// @Success 200 {object} models.Payment
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/payments/{id} [get]
+func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.service.GetPayment(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to get payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// CapturePayment godoc
+// @Summary Списать платеж
+// @Description Списывает авторизованную сумму. Без суммы списывается весь платеж
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID платежа"
+// @Param amount body models.PaymentAmountRequest false "Сумма списания"
+// @Success 200 {object} models.Payment
+// @Failure 400 {string} string
+// @Failure 402 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 502 {string} string
+// @Router /admin/payments/{id}/capture [post]
+func (h *PaymentHandler) CapturePayment(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := paymentOperationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	payment, err := h.service.CapturePayment(r.Context(), id, req.Amount)
+	if err != nil {
+		writePaymentError(w, err, "Failed to capture payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// VoidPayment godoc
+// @Summary Отменить авторизацию
+// @Tags payments
+// @Produce json
+// @Param id path int true "ID платежа"
+// @Success 200 {object} models.Payment
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 502 {string} string
+// @Router /admin/payments/{id}/void [post]
+func (h *PaymentHandler) VoidPayment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.service.VoidPayment(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to void payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// RefundPayment godoc
+// @Summary Вернуть платеж
+// @Description Возвращает указанную сумму или весь остаток. Повтор с тем же Idempotency-Key не создает второй возврат
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID платежа"
+// @Param Idempotency-Key header string true "Ключ идемпотентности"
+// @Param amount body models.PaymentAmountRequest false "Сумма возврата"
+// @Success 200 {object} models.Payment
+// @Failure 400 {string} string
+// @Failure 402 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 502 {string} string
+// @Router /admin/payments/{id}/refund [post]
+func (h *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := paymentOperationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	payment, err := h.service.RefundPayment(r.Context(), id, req.Amount, r.Header.Get("Idempotency-Key"))
+	if err != nil {
+		writePaymentError(w, err, "Failed to refund payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// paymentOperationRequest разбирает ID платежа и необязательное тело с суммой
+func paymentOperationRequest(w http.ResponseWriter, r *http.Request) (int64, models.PaymentAmountRequest, bool) {
+	var req models.PaymentAmountRequest
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return 0, req, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return 0, req, false
+	}
+	return id, req, true
+}
+
+func writePaymentError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrIdempotencyKeyRequired):
+		http.Error(w, "Idempotency-Key header is required", http.StatusBadRequest)
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrCustomerBlocked):
+		http.Error(w, "Customer is blocked", http.StatusForbidden)
+	case errors.Is(err, service.ErrInvalidPayment):
+		http.Error(w, "Invalid payment", http.StatusBadRequest)
+	case errors.Is(err, service.ErrInvalidPaymentAmount):
+		http.Error(w, "Invalid payment amount", http.StatusBadRequest)
+	case errors.Is(err, service.ErrPaymentDeclined):
+		http.Error(w, "Payment declined", http.StatusPaymentRequired)
+	case errors.Is(err, repository.ErrPaymentNotFound):
+		http.Error(w, "Payment not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidPaymentState):
+		http.Error(w, "Operation is not allowed in current payment state", http.StatusConflict)
+	case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+		http.Error(w, "Idempotency-Key was used with different parameters", http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrPaymentProviderFailed):
+		http.Error(w, "Payment provider is unavailable", http.StatusBadGateway)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
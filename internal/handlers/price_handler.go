@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PriceHandler struct {
+	service *service.PricingService
+}
+
+func NewPriceHandler(service *service.PricingService) *PriceHandler {
+	return &PriceHandler{service: service}
+}
+
+// GetPriceHistory godoc
+// @Summary История цен продукта
+// @Description Возвращает последние изменения цены продукта
+// @Tags prices
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {array} models.PriceChange
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/price-history [get]
+func (h *PriceHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get price history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetScheduledPrices godoc
+// @Summary Запланированные цены продукта
+// @Tags prices
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {array} models.ScheduledPrice
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/scheduled-prices [get]
+func (h *PriceHandler) GetScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.service.ListScheduledPrices(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get scheduled prices", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CreateScheduledPrice godoc
+// @Summary Запланировать цену
+// @Description Создает цену, действующую в заданном интервале (например, распродажа)
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param schedule body models.CreateScheduledPriceRequest true "Цена и период действия"
+// @Success 201 {object} models.ScheduledPrice
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/scheduled-prices [post]
+func (h *PriceHandler) CreateScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateScheduledPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.service.CreateScheduledPrice(r.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			http.Error(w, "Product not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidScheduledPrice):
+			http.Error(w, "Invalid scheduled price", http.StatusBadRequest)
+		case errors.Is(err, service.ErrScheduleOverlap):
+			http.Error(w, "Scheduled price overlaps existing schedule", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create scheduled price", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// DeleteScheduledPrice godoc
+// @Summary Удалить запланированную цену
+// @Tags prices
+// @Param id path int true "ID продукта"
+// @Param scheduleId path int true "ID запланированной цены"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/scheduled-prices/{scheduleId} [delete]
+func (h *PriceHandler) DeleteScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	scheduleID, err := strconv.ParseInt(chi.URLParam(r, "scheduleId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid scheduled price ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteScheduledPrice(r.Context(), id, scheduleID); err != nil {
+		if errors.Is(err, repository.ErrScheduledPriceNotFound) {
+			http.Error(w, "Scheduled price not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete scheduled price", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
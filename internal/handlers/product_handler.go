@@ -2,17 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
-	"errors"
 	"shop-api/internal/models"
+	"shop-api/internal/repository"
 	"shop-api/internal/service"
 
 	"github.com/go-chi/chi/v5"
 )
 
-var ErrProductNotFound = errors.New("product not found")
+var ErrProductNotFound = repository.ErrProductNotFound
 
 type ProductHandler struct {
 	service *service.ProductService
@@ -27,11 +28,17 @@ func NewProductHandler(service *service.ProductService) *ProductHandler {
 // @Description Возвращает список всех продуктов
 // @Tags products
 // @Produce json
+// @Param sort query string false "Сортировка: newest (по умолчанию) или rating"
 // @Success 200 {array} models.Product
+// @Failure 400 {string} string
 // @Failure 500 {string} string
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.service.GetAllProducts(r.Context())
+	products, err := h.service.GetAllProducts(r.Context(), r.URL.Query().Get("sort"))
+	if errors.Is(err, service.ErrInvalidProductSort) {
+		http.Error(w, "Invalid sort", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to get products", http.StatusInternalServerError)
 		return
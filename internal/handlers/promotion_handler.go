@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PromotionHandler struct {
+	service *service.PromotionService
+}
+
+func NewPromotionHandler(service *service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+// GetPromotions godoc
+// @Summary Получить все акции
+// @Tags promotions
+// @Produce json
+// @Success 200 {array} models.Promotion
+// @Failure 500 {string} string
+// @Router /admin/promotions [get]
+func (h *PromotionHandler) GetPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.service.GetAllPromotions(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get promotions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotions)
+}
+
+// GetPromotion godoc
+// @Summary Получить акцию по ID
+// @Tags promotions
+// @Produce json
+// @Param id path int true "ID акции"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/promotions/{id} [get]
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := h.service.GetPromotion(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPromotionNotFound) {
+			http.Error(w, "Promotion not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get promotion", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// CreatePromotion godoc
+// @Summary Создать акцию или промокод
+// @Description Акция без кода применяется автоматически
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param promotion body models.PromotionRequest true "Параметры акции"
+// @Success 201 {object} models.Promotion
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /admin/promotions [post]
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req models.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(r.Context(), &req)
+	if err != nil {
+		writePromotionError(w, err, "Failed to create promotion")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// UpdatePromotion godoc
+// @Summary Обновить акцию
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID акции"
+// @Param promotion body models.PromotionRequest true "Параметры акции"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /admin/promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := h.service.UpdatePromotion(r.Context(), id, &req)
+	if err != nil {
+		writePromotionError(w, err, "Failed to update promotion")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// DeletePromotion godoc
+// @Summary Удалить акцию
+// @Tags promotions
+// @Param id path int true "ID акции"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeletePromotion(r.Context(), id); err != nil {
+		writePromotionError(w, err, "Failed to delete promotion")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EvaluateCart godoc
+// @Summary Рассчитать скидки для корзины
+// @Description Применяет автоматические акции и промокоды, возвращает детализацию скидок
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param cart body models.EvaluateCartRequest true "Позиции корзины и промокоды"
+// @Success 200 {object} models.DiscountBreakdown
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /promotions/evaluate [post]
+func (h *PromotionHandler) EvaluateCart(w http.ResponseWriter, r *http.Request) {
+	var req models.EvaluateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	breakdown, err := h.service.EvaluateCart(r.Context(), &req)
+	if err != nil {
+		writeCartError(w, err, "Failed to evaluate promotions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakdown)
+}
+
+func writePromotionError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPromotion):
+		http.Error(w, "Invalid promotion", http.StatusBadRequest)
+	case errors.Is(err, service.ErrPromotionLimitsUnsupported):
+		http.Error(w, "Usage limits are not supported until orders are placed through the API", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrPromotionNotFound):
+		http.Error(w, "Promotion not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrPromotionCodeExists):
+		http.Error(w, "Promotion code already exists", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
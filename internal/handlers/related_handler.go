@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type RelatedHandler struct {
+	service *service.RelatedService
+}
+
+func NewRelatedHandler(service *service.RelatedService) *RelatedHandler {
+	return &RelatedHandler{service: service}
+}
+
+// GetRelatedProducts godoc
+// @Summary Связанные и похожие товары
+// @Description Возвращает товары, связанные вручную (cross_sell, up_sell, accessory), и затем похожие (similar)
+// @Tags products
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param limit query int false "Количество товаров (по умолчанию 10)"
+// @Success 200 {array} models.RelatedProduct
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/related [get]
+func (h *RelatedHandler) GetRelatedProducts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	related, err := h.service.GetRelated(r.Context(), id, limit)
+	if err != nil {
+		writeRelationError(w, err, "Failed to get related products")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(related)
+}
+
+// GetProductRelations godoc
+// @Summary Связи товара, заданные вручную
+// @Tags products
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {array} models.ProductRelation
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /admin/products/{id}/relations [get]
+func (h *RelatedHandler) GetProductRelations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	relations, err := h.service.GetRelations(r.Context(), id)
+	if err != nil {
+		writeRelationError(w, err, "Failed to get product relations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(relations)
+}
+
+// CreateProductRelation godoc
+// @Summary Связать товары
+// @Description Создает связь или обновляет её позицию. Типы: cross_sell, up_sell, accessory
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param relation body models.ProductRelationRequest true "Связь"
+// @Success 201 {object} models.ProductRelation
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/products/{id}/relations [post]
+func (h *RelatedHandler) CreateProductRelation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ProductRelationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	relation, err := h.service.SaveRelation(r.Context(), id, &req)
+	if err != nil {
+		writeRelationError(w, err, "Failed to save product relation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(relation)
+}
+
+// DeleteProductRelation godoc
+// @Summary Удалить связь товаров
+// @Tags products
+// @Param id path int true "ID продукта"
+// @Param type path string true "Тип связи"
+// @Param relatedId path int true "ID связанного продукта"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/products/{id}/relations/{type}/{relatedId} [delete]
+func (h *RelatedHandler) DeleteProductRelation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	relatedID, err := strconv.ParseInt(chi.URLParam(r, "relatedId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid related product ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteRelation(r.Context(), id, relatedID, chi.URLParam(r, "type")); err != nil {
+		writeRelationError(w, err, "Failed to delete product relation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRelationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRelation):
+		http.Error(w, "Invalid product relation", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrProductNotFound):
+		http.Error(w, "Product not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrRelationNotFound):
+		http.Error(w, "Product relation not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ReviewHandler struct {
+	service *service.ReviewService
+}
+
+func NewReviewHandler(service *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: service}
+}
+
+// GetProductReviews godoc
+// @Summary Отзывы о продукте
+// @Description Возвращает опубликованные отзывы о продукте
+// @Tags reviews
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param sort query string false "Сортировка: newest (по умолчанию) или helpful"
+// @Param limit query int false "Количество записей (по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.ReviewPage
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/reviews [get]
+func (h *ReviewHandler) GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	filter, ok := parseReviewFilter(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	page, err := h.service.GetProductReviews(r.Context(), productID, filter)
+	if err != nil {
+		writeReviewError(w, err, "Failed to get reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// CreateReview godoc
+// @Summary Оставить отзыв
+// @Description Добавляет отзыв текущего клиента о продукте. Отзыв публикуется после модерации
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param review body models.CreateReviewRequest true "Оценка и текст отзыва"
+// @Success 201 {object} models.Review
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /products/{id}/reviews [post]
+func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.CreateReview(r.Context(), productID, &req)
+	if err != nil {
+		writeReviewError(w, err, "Failed to create review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// VoteReviewHelpful godoc
+// @Summary Отметить отзыв как полезный
+// @Description Повторный голос того же клиента не учитывается
+// @Tags reviews
+// @Produce json
+// @Param id path int true "ID отзыва"
+// @Success 200 {object} models.Review
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /reviews/{id}/helpful [post]
+func (h *ReviewHandler) VoteReviewHelpful(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.VoteHelpful(r.Context(), id)
+	if err != nil {
+		writeReviewError(w, err, "Failed to vote for review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// GetReviews godoc
+// @Summary Очередь модерации отзывов
+// @Tags reviews
+// @Produce json
+// @Param status query string false "Статус: pending (по умолчанию), approved, rejected"
+// @Param product_id query int false "ID продукта"
+// @Param limit query int false "Количество записей (по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.ReviewPage
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /admin/reviews [get]
+func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, ok := parseReviewFilter(w, query)
+	if !ok {
+		return
+	}
+	filter.Status = query.Get("status")
+	if v := query.Get("product_id"); v != "" {
+		productID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product_id", http.StatusBadRequest)
+			return
+		}
+		filter.ProductID = &productID
+	}
+
+	page, err := h.service.GetReviews(r.Context(), filter)
+	if err != nil {
+		writeReviewError(w, err, "Failed to get reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// ApproveReview godoc
+// @Summary Одобрить отзыв
+// @Tags reviews
+// @Produce json
+// @Param id path int true "ID отзыва"
+// @Success 200 {object} models.Review
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/reviews/{id}/approve [post]
+func (h *ReviewHandler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.service.ApproveReview)
+}
+
+// RejectReview godoc
+// @Summary Отклонить отзыв
+// @Tags reviews
+// @Produce json
+// @Param id path int true "ID отзыва"
+// @Success 200 {object} models.Review
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/reviews/{id}/reject [post]
+func (h *ReviewHandler) RejectReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.service.RejectReview)
+}
+
+// ReplyToReview godoc
+// @Summary Ответить на отзыв
+// @Description Сохраняет ответ магазина; пустой ответ удаляет его
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "ID отзыва"
+// @Param reply body models.ReviewReplyRequest true "Ответ магазина"
+// @Success 200 {object} models.Review
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/reviews/{id}/reply [put]
+func (h *ReviewHandler) ReplyToReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReviewReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.ReplyToReview(r.Context(), id, &req)
+	if err != nil {
+		writeReviewError(w, err, "Failed to reply to review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// DeleteReview godoc
+// @Summary Удалить отзыв
+// @Tags reviews
+// @Param id path int true "ID отзыва"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/reviews/{id} [delete]
+func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteReview(r.Context(), id); err != nil {
+		writeReviewError(w, err, "Failed to delete review")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ReviewHandler) moderate(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id int64) (*models.Review, error)) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	review, err := action(r.Context(), id)
+	if err != nil {
+		writeReviewError(w, err, "Failed to moderate review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+func parseReviewFilter(w http.ResponseWriter, query url.Values) (models.ReviewFilter, bool) {
+	filter := models.ReviewFilter{Sort: query.Get("sort")}
+
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return filter, false
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+func writeReviewError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReview):
+		http.Error(w, "Invalid review", http.StatusBadRequest)
+	case errors.Is(err, service.ErrOwnReviewVote):
+		http.Error(w, "Cannot vote for own review", http.StatusBadRequest)
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrCustomerBlocked):
+		http.Error(w, "Customer is blocked", http.StatusForbidden)
+	case errors.Is(err, service.ErrCustomerProfileMissing):
+		http.Error(w, "Customer profile is required", http.StatusForbidden)
+	case errors.Is(err, repository.ErrProductNotFound):
+		http.Error(w, "Product not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrReviewNotFound):
+		http.Error(w, "Review not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrReviewExists):
+		http.Error(w, "Review for this product already exists", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ShippingHandler struct {
+	service *service.ShippingService
+}
+
+func NewShippingHandler(service *service.ShippingService) *ShippingHandler {
+	return &ShippingHandler{service: service}
+}
+
+// GetShippingRates godoc
+// @Summary Способы доставки для корзины
+// @Description Возвращает доступные способы доставки и их стоимость для адреса назначения
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param cart body models.ShippingRatesRequest true "Позиции корзины и адрес доставки"
+// @Success 200 {array} models.ShippingRate
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /shipping/rates [post]
+func (h *ShippingHandler) GetShippingRates(w http.ResponseWriter, r *http.Request) {
+	var req models.ShippingRatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rates, err := h.service.CartRates(r.Context(), &req)
+	if err != nil {
+		writeCartError(w, err, "Failed to get shipping rates")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// GetShippingZones godoc
+// @Summary Получить зоны доставки
+// @Tags shipping
+// @Produce json
+// @Success 200 {array} models.ShippingZone
+// @Failure 500 {string} string
+// @Router /admin/shipping-zones [get]
+func (h *ShippingHandler) GetShippingZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.service.GetZones(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get shipping zones", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(zones)
+}
+
+// CreateShippingZone godoc
+// @Summary Создать зону доставки
+// @Description Создает зону вместе со способами доставки
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param zone body models.ShippingZoneRequest true "Зона доставки"
+// @Success 201 {object} models.ShippingZone
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /admin/shipping-zones [post]
+func (h *ShippingHandler) CreateShippingZone(w http.ResponseWriter, r *http.Request) {
+	var req models.ShippingZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.service.CreateZone(r.Context(), &req)
+	if err != nil {
+		writeShippingZoneError(w, err, "Failed to create shipping zone")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(zone)
+}
+
+// UpdateShippingZone godoc
+// @Summary Обновить зону доставки
+// @Description Заменяет параметры зоны и список её способов доставки
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param id path int true "ID зоны"
+// @Param zone body models.ShippingZoneRequest true "Зона доставки"
+// @Success 200 {object} models.ShippingZone
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/shipping-zones/{id} [put]
+func (h *ShippingHandler) UpdateShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid shipping zone ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ShippingZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.service.UpdateZone(r.Context(), id, &req)
+	if err != nil {
+		writeShippingZoneError(w, err, "Failed to update shipping zone")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(zone)
+}
+
+// DeleteShippingZone godoc
+// @Summary Удалить зону доставки
+// @Tags shipping
+// @Param id path int true "ID зоны"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/shipping-zones/{id} [delete]
+func (h *ShippingHandler) DeleteShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid shipping zone ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteZone(r.Context(), id); err != nil {
+		writeShippingZoneError(w, err, "Failed to delete shipping zone")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeShippingZoneError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidShippingZone):
+		http.Error(w, "Invalid shipping zone", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrShippingZoneNotFound):
+		http.Error(w, "Shipping zone not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrShippingMethodNotFound):
+		http.Error(w, "Shipping method not found in zone", http.StatusBadRequest)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TaxHandler struct {
+	service *service.TaxService
+}
+
+func NewTaxHandler(service *service.TaxService) *TaxHandler {
+	return &TaxHandler{service: service}
+}
+
+// GetTaxRates godoc
+// @Summary Получить ставки налогов
+// @Tags tax
+// @Produce json
+// @Success 200 {array} models.TaxRate
+// @Failure 500 {string} string
+// @Router /admin/tax-rates [get]
+func (h *TaxHandler) GetTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.service.GetAllRates(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get tax rates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// CreateTaxRate godoc
+// @Summary Создать ставку налога
+// @Description Ставка задается для страны (и, опционально, региона) и налогового класса продукта
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param rate body models.TaxRateRequest true "Ставка налога"
+// @Success 201 {object} models.TaxRate
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /admin/tax-rates [post]
+func (h *TaxHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var req models.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rate, err := h.service.CreateRate(r.Context(), &req)
+	if err != nil {
+		writeTaxRateError(w, err, "Failed to create tax rate")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rate)
+}
+
+// UpdateTaxRate godoc
+// @Summary Обновить ставку налога
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param id path int true "ID ставки"
+// @Param rate body models.TaxRateRequest true "Ставка налога"
+// @Success 200 {object} models.TaxRate
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /admin/tax-rates/{id} [put]
+func (h *TaxHandler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rate, err := h.service.UpdateRate(r.Context(), id, &req)
+	if err != nil {
+		writeTaxRateError(w, err, "Failed to update tax rate")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rate)
+}
+
+// DeleteTaxRate godoc
+// @Summary Удалить ставку налога
+// @Tags tax
+// @Param id path int true "ID ставки"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/tax-rates/{id} [delete]
+func (h *TaxHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteRate(r.Context(), id); err != nil {
+		writeTaxRateError(w, err, "Failed to delete tax rate")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTaxRateError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTaxRate):
+		http.Error(w, "Invalid tax rate", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrTaxRateNotFound):
+		http.Error(w, "Tax rate not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrTaxRateExists):
+		http.Error(w, "Tax rate already exists", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
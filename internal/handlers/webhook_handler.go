@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// GetWebhookSubscriptions godoc
+// @Summary Подписки на вебхуки
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 500 {string} string
+// @Router /admin/webhooks [get]
+func (h *WebhookHandler) GetWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get webhook subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// GetWebhookSubscription godoc
+// @Summary Получить подписку на вебхуки
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.GetSubscription(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to get webhook subscription")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// CreateWebhookSubscription godoc
+// @Summary Создать подписку на вебхуки
+// @Description Секрет для проверки подписи возвращается только в этом ответе; без секрета в запросе он генерируется
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param subscription body models.WebhookSubscriptionRequest true "Подписка"
+// @Success 201 {object} models.WebhookSubscription
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks [post]
+func (h *WebhookHandler) CreateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), &req)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to create webhook subscription")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// UpdateWebhookSubscription godoc
+// @Summary Обновить подписку на вебхуки
+// @Description Включение отключенной подписки сбрасывает счетчик неудачных доставок
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param subscription body models.WebhookSubscriptionRequest true "Подписка"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(r.Context(), id, &req)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to update webhook subscription")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// DeleteWebhookSubscription godoc
+// @Summary Удалить подписку на вебхуки
+// @Tags admin
+// @Param id path int true "ID подписки"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteSubscription(r.Context(), id); err != nil {
+		writeWebhookError(w, err, "Failed to delete webhook subscription")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Журнал доставок подписки
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param limit query int false "Количество записей (по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	var limit, offset int
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), id, limit, offset)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetWebhookDelivery godoc
+// @Summary Доставка вебхука с журналом попыток
+// @Tags admin
+// @Produce json
+// @Param deliveryId path int true "ID доставки"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks/deliveries/{deliveryId} [get]
+func (h *WebhookHandler) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "deliveryId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.GetDelivery(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to get webhook delivery")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// RedeliverWebhook godoc
+// @Summary Повторить доставку вебхука
+// @Description Доставка ставится в очередь и отправляется фоновым обработчиком
+// @Tags admin
+// @Produce json
+// @Param deliveryId path int true "ID доставки"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /admin/webhooks/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "deliveryId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.Redeliver(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to redeliver webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+func writeWebhookError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhookSubscription):
+		http.Error(w, "Invalid webhook subscription", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrWebhookSubscriptionNotFound):
+		http.Error(w, "Webhook subscription not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrWebhookDeliveryNotFound):
+		http.Error(w, "Webhook delivery not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WishlistHandler struct {
+	service *service.WishlistService
+}
+
+func NewWishlistHandler(service *service.WishlistService) *WishlistHandler {
+	return &WishlistHandler{service: service}
+}
+
+// GetWishlists godoc
+// @Summary Списки желаний текущего пользователя
+// @Tags wishlists
+// @Produce json
+// @Success 200 {array} models.Wishlist
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists [get]
+func (h *WishlistHandler) GetWishlists(w http.ResponseWriter, r *http.Request) {
+	wishlists, err := h.service.GetWishlists(r.Context())
+	if err != nil {
+		writeWishlistError(w, err, "Failed to get wishlists")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wishlists)
+}
+
+// CreateWishlist godoc
+// @Summary Создать список желаний
+// @Tags wishlists
+// @Accept json
+// @Produce json
+// @Param wishlist body models.WishlistRequest true "Название списка"
+// @Success 201 {object} models.Wishlist
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists [post]
+func (h *WishlistHandler) CreateWishlist(w http.ResponseWriter, r *http.Request) {
+	var req models.WishlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wishlist, err := h.service.CreateWishlist(r.Context(), &req)
+	if err != nil {
+		writeWishlistError(w, err, "Failed to create wishlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wishlist)
+}
+
+// GetWishlist godoc
+// @Summary Получить список желаний
+// @Tags wishlists
+// @Produce json
+// @Param id path int true "ID списка"
+// @Success 200 {object} models.Wishlist
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id} [get]
+func (h *WishlistHandler) GetWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+
+	wishlist, err := h.service.GetWishlist(r.Context(), id)
+	if err != nil {
+		writeWishlistError(w, err, "Failed to get wishlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wishlist)
+}
+
+// RenameWishlist godoc
+// @Summary Переименовать список желаний
+// @Tags wishlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID списка"
+// @Param wishlist body models.WishlistRequest true "Название списка"
+// @Success 200 {object} models.Wishlist
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id} [put]
+func (h *WishlistHandler) RenameWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.WishlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wishlist, err := h.service.RenameWishlist(r.Context(), id, &req)
+	if err != nil {
+		writeWishlistError(w, err, "Failed to update wishlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wishlist)
+}
+
+// DeleteWishlist godoc
+// @Summary Удалить список желаний
+// @Tags wishlists
+// @Param id path int true "ID списка"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id} [delete]
+func (h *WishlistHandler) DeleteWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWishlist(r.Context(), id); err != nil {
+		writeWishlistError(w, err, "Failed to delete wishlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddWishlistItem godoc
+// @Summary Добавить товар в список желаний
+// @Tags wishlists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID списка"
+// @Param item body models.WishlistItemRequest true "Товар"
+// @Success 200 {object} models.Wishlist
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id}/items [post]
+func (h *WishlistHandler) AddWishlistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.WishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wishlist, err := h.service.AddItem(r.Context(), id, &req)
+	if err != nil {
+		writeWishlistError(w, err, "Failed to add wishlist item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wishlist)
+}
+
+// RemoveWishlistItem godoc
+// @Summary Удалить товар из списка желаний
+// @Tags wishlists
+// @Param id path int true "ID списка"
+// @Param productId path int true "ID продукта"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id}/items/{productId} [delete]
+func (h *WishlistHandler) RemoveWishlistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+	productID, err := strconv.ParseInt(chi.URLParam(r, "productId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RemoveItem(r.Context(), id, productID); err != nil {
+		writeWishlistError(w, err, "Failed to remove wishlist item")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareWishlist godoc
+// @Summary Опубликовать список по ссылке
+// @Description Создает новый случайный токен ссылки; предыдущая ссылка перестает работать
+// @Tags wishlists
+// @Produce json
+// @Param id path int true "ID списка"
+// @Success 200 {object} models.Wishlist
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id}/share [post]
+func (h *WishlistHandler) ShareWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+
+	wishlist, err := h.service.Share(r.Context(), id)
+	if err != nil {
+		writeWishlistError(w, err, "Failed to share wishlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wishlist)
+}
+
+// UnshareWishlist godoc
+// @Summary Закрыть доступ к списку по ссылке
+// @Tags wishlists
+// @Param id path int true "ID списка"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/{id}/share [delete]
+func (h *WishlistHandler) UnshareWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid wishlist ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Unshare(r.Context(), id); err != nil {
+		writeWishlistError(w, err, "Failed to unshare wishlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSharedWishlist godoc
+// @Summary Список желаний по публичной ссылке
+// @Tags wishlists
+// @Produce json
+// @Param token path string true "Токен ссылки"
+// @Success 200 {object} models.Wishlist
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/shared/{token} [get]
+func (h *WishlistHandler) GetSharedWishlist(w http.ResponseWriter, r *http.Request) {
+	wishlist, err := h.service.GetSharedWishlist(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeWishlistError(w, err, "Failed to get wishlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wishlist)
+}
+
+// GetWishlistNotifications godoc
+// @Summary Уведомления о товарах из списков желаний
+// @Description Поступление в продажу и снижение цены; последние 100 уведомлений
+// @Tags wishlists
+// @Produce json
+// @Success 200 {array} models.WishlistNotification
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/notifications [get]
+func (h *WishlistHandler) GetWishlistNotifications(w http.ResponseWriter, r *http.Request) {
+	notifications, err := h.service.GetNotifications(r.Context())
+	if err != nil {
+		writeWishlistError(w, err, "Failed to get notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// MarkWishlistNotificationRead godoc
+// @Summary Отметить уведомление прочитанным
+// @Tags wishlists
+// @Param id path int true "ID уведомления"
+// @Success 204 "No Content"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /wishlists/notifications/{id}/read [post]
+func (h *WishlistHandler) MarkWishlistNotificationRead(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.MarkNotificationRead(r.Context(), id); err != nil {
+		writeWishlistError(w, err, "Failed to update notification")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeWishlistError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWishlist):
+		http.Error(w, "Invalid wishlist", http.StatusBadRequest)
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrCustomerBlocked):
+		http.Error(w, "Customer is blocked", http.StatusForbidden)
+	case errors.Is(err, repository.ErrWishlistNotFound):
+		http.Error(w, "Wishlist not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrWishlistItemNotFound):
+		http.Error(w, "Wishlist item not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrNotificationNotFound):
+		http.Error(w, "Notification not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrProductNotFound):
+		http.Error(w, "Product not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrWishlistExists):
+		http.Error(w, "Wishlist with this name already exists", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
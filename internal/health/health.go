@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+)
+
+// CheckFunc проверяет доступность зависимости
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	// Отказ обязательной зависимости делает сервис неготовым, остальных - работающим с ограничениями
+	critical bool
+	fn       CheckFunc
+}
+
+// Report - ответ /readyz
+type Report struct {
+	Status       string                 `json:"status"`
+	ShuttingDown bool                   `json:"shutting_down,omitempty"`
+	Checks       map[string]CheckResult `json:"checks"`
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Checker выполняет проверки зависимостей для /readyz.
+// После SetShuttingDown готовность всегда отрицательная, чтобы балансировщик убрал экземпляр.
+type Checker struct {
+	timeout      time.Duration
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку; вызывается до запуска сервера
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check выполняет все проверки параллельно
+func (c *Checker) Check(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := ch.fn(ctx)
+			results[i] = CheckResult{
+				Status:    StatusUp,
+				Critical:  ch.critical,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = StatusDown
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(c.checks))}
+	for i, ch := range c.checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status == StatusUp {
+			continue
+		}
+		if ch.critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	if c.shuttingDown.Load() {
+		report.Status = StatusDown
+		report.ShuttingDown = true
+	}
+	return report
+}
+
+// Liveness отвечает 200, пока процесс обрабатывает запросы
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": StatusUp})
+}
+
+// Readiness отвечает 503, если недоступна обязательная зависимость или сервер останавливается.
+// При отказе только необязательных зависимостей ответ 200 со статусом degraded.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == StatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
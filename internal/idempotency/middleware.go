@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"shop-api/internal/auth"
+	"shop-api/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// Тело запроса больше этого размера не принимается
+	maxBodySize = 1 << 20
+	// Сколько держится ключ незавершенного запроса, если сервер упал, не дождавшись ответа
+	lockTTL = time.Minute
+)
+
+// Заголовки ответа, которые воспроизводятся при повторе
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Store хранит ключи идемпотентности и сохраненные ответы
+type Store interface {
+	Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, record *models.IdempotencyRecord, retention time.Duration) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Middleware обрабатывает POST-запросы аутентифицированных пользователей с заголовком
+// Idempotency-Key: первый запрос выполняется,
+// а повторы с тем же ключом получают сохраненный ответ. Повтор во время выполнения первого запроса
+// получает 409, тот же ключ с другим запросом - 422. Ответы 5xx не сохраняются, чтобы клиент мог повторить.
+type Middleware struct {
+	store     Store
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+func New(store Store, retention time.Duration, logger *slog.Logger) *Middleware {
+	return &Middleware{store: store, retention: retention, logger: logger}
+}
+
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+		// Ключ действует в пределах пользователя: без аутентификации чужой ключ
+		// позволил бы получить сохраненный ответ другого клиента
+		principal, ok := auth.Principal(r.Context())
+		if !ok {
+			http.Error(w, "Idempotency-Key requires authentication", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxBodySize {
+			http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := principal + ":" + key
+		fingerprint := requestFingerprint(r, body)
+
+		record, created, err := m.store.Begin(r.Context(), storeKey, fingerprint, lockTTL)
+		if err != nil {
+			m.logger.ErrorContext(r.Context(), "idempotency store error", "error", err)
+			http.Error(w, "Failed to check Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+		if !created {
+			switch {
+			case record.Fingerprint != fingerprint:
+				http.Error(w, "Idempotency-Key was used with a different request", http.StatusUnprocessableEntity)
+			case !record.Completed:
+				http.Error(w, "Request with this Idempotency-Key is in progress", http.StatusConflict)
+			default:
+				replay(w, record)
+			}
+			return
+		}
+
+		var buf bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&buf)
+
+		// Ключ освобождается и при панике обработчика
+		completed := false
+		defer func() {
+			if !completed {
+				m.release(r.Context(), storeKey)
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		record = &models.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			Header:      make(map[string][]string),
+			Body:        buf.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				record.Header[name] = values
+			}
+		}
+		// Ответ уже отправлен, поэтому контекст запроса может быть отменен
+		if err := m.store.Complete(context.WithoutCancel(r.Context()), storeKey, record, m.retention); err != nil {
+			m.logger.ErrorContext(r.Context(), "idempotency store error", "error", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// RunCleanup периодически удаляет истекшие ключи из хранилища
+func (m *Middleware) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := m.store.DeleteExpired(ctx)
+		if err != nil {
+			m.logger.ErrorContext(ctx, "idempotency cleanup error", "error", err)
+		} else if deleted > 0 {
+			m.logger.InfoContext(ctx, "expired idempotency keys deleted", "count", deleted)
+		}
+	}
+}
+
+func (m *Middleware) release(ctx context.Context, key string) {
+	if err := m.store.Release(context.WithoutCancel(ctx), key); err != nil {
+		m.logger.ErrorContext(ctx, "idempotency store error", "error", err)
+	}
+}
+
+// requestFingerprint учитывает метод, путь с параметрами и тело запроса
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, record *models.IdempotencyRecord) {
+	for name, values := range record.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
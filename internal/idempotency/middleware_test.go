@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shop-api/internal/auth"
+	"shop-api/internal/models"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func (s *memoryStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok {
+		return record, false, nil
+	}
+	s.records[key] = &models.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, record *models.IdempotencyRecord, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.Completed = true
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestMiddlewareScopesKeysByPrincipal(t *testing.T) {
+	calls := 0
+	handler := New(&memoryStore{records: map[string]*models.IdempotencyRecord{}}, time.Hour, slog.New(slog.DiscardHandler)).
+		Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(strconv.Itoa(calls)))
+		}))
+
+	send := func(identity *auth.Identity) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/payments", strings.NewReader(`{"amount":10}`))
+		req.Header.Set(HeaderKey, "key-1")
+		if identity != nil {
+			req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name         string
+		identity     *auth.Identity
+		wantStatus   int
+		wantBody     string
+		wantReplayed bool
+	}{
+		{name: "anonymous", wantStatus: http.StatusUnauthorized},
+		{name: "first user", identity: &auth.Identity{UserID: 1}, wantStatus: http.StatusCreated, wantBody: "1"},
+		{name: "first user repeat", identity: &auth.Identity{UserID: 1}, wantStatus: http.StatusCreated, wantBody: "1", wantReplayed: true},
+		{name: "second user same key", identity: &auth.Identity{UserID: 2}, wantStatus: http.StatusCreated, wantBody: "2"},
+	}
+	for _, tt := range tests {
+		rec := send(tt.identity)
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body.String(), tt.wantBody)
+		}
+		if replayed := rec.Header().Get(HeaderReplayed) == "true"; replayed != tt.wantReplayed {
+			t.Errorf("%s: replayed = %v, want %v", tt.name, replayed, tt.wantReplayed)
+		}
+	}
+}
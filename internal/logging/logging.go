@@ -0,0 +1,184 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	redacted = "[REDACTED]"
+)
+
+// Ключи атрибутов, значения которых не должны попадать в логи
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "api_key", "apikey", "cookie", "card"}
+
+// Manager создает логгеры компонентов и хранит их уровни, которые можно менять во время работы.
+// Компонент без собственного уровня использует уровень по умолчанию.
+type Manager struct {
+	base         slog.Handler
+	defaultLevel *slog.LevelVar
+
+	mu     sync.RWMutex
+	levels map[string]*componentLevel
+}
+
+type componentLevel struct {
+	level *slog.LevelVar
+	// Уровень задан явно и не меняется вместе с уровнем по умолчанию
+	explicit bool
+}
+
+func New(w io.Writer, format string, level slog.Level) (*Manager, error) {
+	opts := &slog.HandlerOptions{
+		// Фильтрация выполняется по уровню компонента
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redact,
+	}
+
+	var base slog.Handler
+	switch format {
+	case FormatJSON:
+		base = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		base = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+
+	defaultLevel := new(slog.LevelVar)
+	defaultLevel.Set(level)
+	return &Manager{
+		base:         contextHandler{base},
+		defaultLevel: defaultLevel,
+		levels:       make(map[string]*componentLevel),
+	}, nil
+}
+
+// Logger возвращает логгер компонента; все записи содержат атрибут component
+func (m *Manager) Logger(component string) *slog.Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cl, ok := m.levels[component]
+	if !ok {
+		cl = &componentLevel{level: new(slog.LevelVar)}
+		cl.level.Set(m.defaultLevel.Level())
+		m.levels[component] = cl
+	}
+	return slog.New(levelHandler{inner: m.base, level: cl.level}).With("component", component)
+}
+
+// Levels возвращает уровень по умолчанию и уровни всех компонентов
+func (m *Manager) Levels() (string, map[string]string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	levels := make(map[string]string, len(m.levels))
+	for name, cl := range m.levels {
+		levels[name] = strings.ToLower(cl.level.Level().String())
+	}
+	return strings.ToLower(m.defaultLevel.Level().String()), levels
+}
+
+// SetLevel меняет уровень компонента. Пустой компонент меняет уровень по умолчанию
+// и уровни всех компонентов, для которых уровень не задан явно.
+func (m *Manager) SetLevel(component string, level slog.Level) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if component == "" {
+		m.defaultLevel.Set(level)
+		for _, cl := range m.levels {
+			if !cl.explicit {
+				cl.level.Set(level)
+			}
+		}
+		return true
+	}
+
+	cl, ok := m.levels[component]
+	if !ok {
+		return false
+	}
+	cl.level.Set(level)
+	cl.explicit = true
+	return true
+}
+
+// ParseLevel разбирает уровень debug, info, warn или error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// levelHandler отбрасывает записи ниже уровня компонента
+type levelHandler struct {
+	inner slog.Handler
+	level *slog.LevelVar
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{inner: h.inner.WithAttrs(attrs), level: h.level}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{inner: h.inner.WithGroup(name), level: h.level}
+}
+
+// contextHandler добавляет к записи ID запроса и идентификаторы трассировки из контекста
+type contextHandler struct {
+	inner slog.Handler
+}
+
+func (h contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := middleware.GetReqID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.inner.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.inner.WithGroup(name)}
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shop"
+
+// Registry содержит все метрики приложения
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	redisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_command_errors_total",
+		Help:      "Redis command errors by command, cache misses excluded.",
+	}, []string{"command"})
+
+	// CacheRequests - обращения к кэшу; hit ratio = hit / (hit + miss).
+	// bypass - кэш пропущен, потому что разомкнут предохранитель.
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache name and result (hit, miss or bypass).",
+	}, []string{"cache", "result"})
+
+	// CircuitOpen - состояние предохранителя: 1, если цепь разомкнута
+	CircuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "circuit_breaker_open",
+		Help:      "Whether the circuit breaker is open (1) or closed (0).",
+	}, []string{"breaker"})
+
+	CircuitTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "circuit_breaker_transitions_total",
+		Help:      "Circuit breaker state changes by breaker and new state.",
+	}, []string{"breaker", "state"})
+
+	// RateLimited - запросы, отклоненные ограничением частоты
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected by the rate limiter by rule.",
+	}, []string{"rule"})
+
+	// DomainEvents - опубликованные доменные события по типу
+	DomainEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "domain_events_total",
+		Help:      "Published domain events by type.",
+	}, []string{"type"})
+
+	// DBReads - чтения каталога по серверу: primary или replica
+	DBReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_reads_total",
+		Help:      "Routed read queries by target (primary or replica).",
+	}, []string{"target"})
+
+	DBReplicaAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_available",
+		Help:      "Whether the replica is used for reads (1) or skipped (0).",
+	}, []string{"replica"})
+
+	DBReplicaLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_lag_seconds",
+		Help:      "Replication lag of the replica at the last check.",
+	}, []string{"replica"})
+
+	// LowStockProducts обновляется фоновой задачей, а не при сборе, чтобы сбор метрик не нагружал базу
+	LowStockProducts = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "low_stock_products",
+		Help:      "Products with stock at or below the low stock threshold at the last check.",
+	})
+
+	// ConfigReloads - попытки перечитать конфигурацию: success или failure
+	ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Configuration reload attempts by result.",
+	}, []string{"result"})
+
+	ConfigLastReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Unix time of the last successful configuration load.",
+	})
+
+	ConfigLastReloadSuccessful = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_successful",
+		Help:      "Whether the last configuration reload succeeded (1) or failed (0).",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		redisCommandDuration,
+		redisErrors,
+		CacheRequests,
+		CircuitOpen,
+		CircuitTransitions,
+		RateLimited,
+		DomainEvents,
+		DBReads,
+		DBReplicaAvailable,
+		DBReplicaLag,
+		LowStockProducts,
+		ConfigReloads,
+		ConfigLastReloadSuccess,
+		ConfigLastReloadSuccessful,
+		dbPools,
+	)
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware считает запросы и их длительность по шаблону маршрута chi,
+// чтобы ID в пути не порождали отдельные ряды
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RegisterDBPool добавляет статистику пула соединений с базой; name попадает в метку pool
+func RegisterDBPool(name string, pool *pgxpool.Pool) {
+	dbPools.mu.Lock()
+	defer dbPools.mu.Unlock()
+	dbPools.pools[name] = pool
+}
+
+// poolCollector собирает статистику всех пулов: основного сервера и реплик
+type poolCollector struct {
+	mu    sync.Mutex
+	pools map[string]*pgxpool.Pool
+}
+
+var dbPools = &poolCollector{pools: make(map[string]*pgxpool.Pool)}
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(namespace+"_db_pool_acquired_conns",
+		"Connections currently in use.", []string{"pool"}, nil)
+	poolIdleConns = prometheus.NewDesc(namespace+"_db_pool_idle_conns",
+		"Idle connections in the pool.", []string{"pool"}, nil)
+	poolTotalConns = prometheus.NewDesc(namespace+"_db_pool_total_conns",
+		"Total connections in the pool.", []string{"pool"}, nil)
+	poolMaxConns = prometheus.NewDesc(namespace+"_db_pool_max_conns",
+		"Maximum pool size.", []string{"pool"}, nil)
+	poolAcquires = prometheus.NewDesc(namespace+"_db_pool_acquires_total",
+		"Successful connection acquires.", []string{"pool"}, nil)
+	poolEmptyAcquires = prometheus.NewDesc(namespace+"_db_pool_empty_acquires_total",
+		"Acquires that had to wait for a connection.", []string{"pool"}, nil)
+	poolCanceledAcquires = prometheus.NewDesc(namespace+"_db_pool_canceled_acquires_total",
+		"Acquires canceled by context.", []string{"pool"}, nil)
+	poolAcquireDuration = prometheus.NewDesc(namespace+"_db_pool_acquire_duration_seconds_total",
+		"Total time spent acquiring connections.", []string{"pool"}, nil)
+)
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquires
+	ch <- poolEmptyAcquires
+	ch <- poolCanceledAcquires
+	ch <- poolAcquireDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, pool := range c.pools {
+		collectPool(ch, name, pool.Stat())
+	}
+}
+
+func collectPool(ch chan<- prometheus.Metric, name string, stat *pgxpool.Stat) {
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), name)
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()), name)
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()), name)
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()), name)
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), name)
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), name)
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()), name)
+	ch <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds(), name)
+}
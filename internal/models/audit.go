@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// FieldChange описывает изменение одного поля сущности
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type AuditEntry struct {
+	ID         int64                  `json:"id"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int64                  `json:"entity_id"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"`
+	RequestID  string                 `json:"request_id"`
+	IP         string                 `json:"ip"`
+	Changes    map[string]FieldChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   int64
+	Actor      string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,53 @@
+package models
+
+// Cart - набор позиций с ценами, для которого рассчитываются скидки, налоги и доставка
+type Cart struct {
+	Items        []CartItem `json:"items"`
+	ShippingCost float64    `json:"shipping_cost"`
+	Codes        []string   `json:"codes,omitempty"`
+}
+
+type CartItem struct {
+	ProductID int64   `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Category  string  `json:"category"`
+	TaxClass  string  `json:"tax_class"`
+	WeightKg  float64 `json:"weight_kg"`
+	VolumeCm3 float64 `json:"volume_cm3"`
+}
+
+func (c *Cart) Subtotal() float64 {
+	var subtotal float64
+	for _, item := range c.Items {
+		subtotal += item.UnitPrice * float64(item.Quantity)
+	}
+	return subtotal
+}
+
+type CartItemRequest struct {
+	ProductID int64 `json:"product_id" binding:"required"`
+	Quantity  int   `json:"quantity" binding:"required,min=1"`
+}
+
+type EvaluateCartRequest struct {
+	Items        []CartItemRequest `json:"items" binding:"required"`
+	ShippingCost float64           `json:"shipping_cost"`
+	Codes        []string          `json:"codes"`
+}
+
+type CartTotalsRequest struct {
+	Items        []CartItemRequest `json:"items" binding:"required"`
+	ShippingCost float64           `json:"shipping_cost"`
+	// Если указан способ доставки, стоимость рассчитывается по нему вместо ShippingCost
+	ShippingMethodID int64       `json:"shipping_method_id"`
+	Codes            []string    `json:"codes"`
+	Destination      Destination `json:"destination" binding:"required"`
+}
+
+// CartTotals - итог корзины со скидками и налогами
+type CartTotals struct {
+	Discounts *DiscountBreakdown `json:"discounts"`
+	Tax       *TaxResult         `json:"tax"`
+	Total     float64            `json:"total"`
+}
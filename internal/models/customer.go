@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	AddressTypeShipping = "shipping"
+	AddressTypeBilling  = "billing"
+)
+
+type Customer struct {
+	ID        int64      `json:"id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Phone     string     `json:"phone"`
+	Blocked   bool       `json:"blocked"`
+	BlockedAt *time.Time `json:"blocked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type UpdateProfileRequest struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+}
+
+type Address struct {
+	ID         int64     `json:"id"`
+	CustomerID int64     `json:"customer_id"`
+	Type       string    `json:"type"`
+	IsDefault  bool      `json:"is_default"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2"`
+	City       string    `json:"city"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+	Phone      string    `json:"phone"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type AddressRequest struct {
+	Type       string `json:"type" binding:"required"`
+	IsDefault  bool   `json:"is_default"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Line1      string `json:"line1" binding:"required"`
+	Line2      string `json:"line2"`
+	City       string `json:"city" binding:"required"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country" binding:"required"`
+	Phone      string `json:"phone"`
+}
+
+type CustomerFilter struct {
+	Query   string
+	Blocked *bool
+	Limit   int
+	Offset  int
+}
+
+type CustomerPage struct {
+	Items  []*Customer `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AggregateProduct = "product"
+	AggregatePayment = "payment"
+)
+
+const (
+	EventProductCreated       = "product.created"
+	EventProductUpdated       = "product.updated"
+	EventProductDeleted       = "product.deleted"
+	EventStockChanged         = "product.stock_changed"
+	EventPaymentStatusChanged = "payment.status_changed"
+)
+
+// Event - доменное событие из outbox. События одного агрегата публикуются в порядке ID.
+type Event struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"-"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type ProductUpdatedEvent struct {
+	Before *Product `json:"before"`
+	After  *Product `json:"after"`
+}
+
+type StockChangedEvent struct {
+	ProductID int64 `json:"product_id"`
+	OldStock  int   `json:"old_stock"`
+	NewStock  int   `json:"new_stock"`
+}
+
+type PaymentStatusChangedEvent struct {
+	OldStatus string   `json:"old_status"`
+	Payment   *Payment `json:"payment"`
+}
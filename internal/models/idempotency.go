@@ -0,0 +1,11 @@
+package models
+
+// IdempotencyRecord - сохраненный запрос с ключом идемпотентности.
+// Пока Completed равен false, запрос еще выполняется.
+type IdempotencyRecord struct {
+	Fingerprint string              `json:"fingerprint"`
+	Completed   bool                `json:"completed"`
+	StatusCode  int                 `json:"status_code"`
+	Header      map[string][]string `json:"header"`
+	Body        []byte              `json:"body"`
+}
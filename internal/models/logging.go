@@ -0,0 +1,13 @@
+package models
+
+// LogLevels - уровень логирования по умолчанию и уровни компонентов
+type LogLevels struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+// LogLevelRequest меняет уровень компонента; пустой компонент - уровень по умолчанию
+type LogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	PaymentStatusPending           = "pending"
+	PaymentStatusAuthorized        = "authorized"
+	PaymentStatusCaptured          = "captured"
+	PaymentStatusVoided            = "voided"
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+	PaymentStatusRefunded          = "refunded"
+	PaymentStatusFailed            = "failed"
+)
+
+const (
+	PaymentOperationAuthorize = "authorize"
+	PaymentOperationCapture   = "capture"
+	PaymentOperationVoid      = "void"
+	PaymentOperationRefund    = "refund"
+	PaymentOperationWebhook   = "webhook"
+)
+
+// Payment - платежное намерение. IdempotencyKey гарантирует, что повтор запроса
+// не создаст второе списание.
+type Payment struct {
+	ID             int64            `json:"id"`
+	OrderID        *int64           `json:"order_id,omitempty"`
+	Amount         float64          `json:"amount"`
+	Currency       string           `json:"currency"`
+	Status         string           `json:"status"`
+	Provider       string           `json:"provider"`
+	ProviderRef    string           `json:"provider_ref"`
+	IdempotencyKey string           `json:"-"`
+	CapturedAmount float64          `json:"captured_amount"`
+	RefundedAmount float64          `json:"refunded_amount"`
+	FailureReason  string           `json:"failure_reason,omitempty"`
+	Attempts       []PaymentAttempt `json:"attempts,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// PaymentAttempt - обращение к платежному провайдеру или событие от него
+type PaymentAttempt struct {
+	ID             int64     `json:"id"`
+	PaymentID      int64     `json:"payment_id"`
+	Operation      string    `json:"operation"`
+	Amount         float64   `json:"amount"`
+	Success        bool      `json:"success"`
+	ProviderRef    string    `json:"provider_ref"`
+	Error          string    `json:"error,omitempty"`
+	IdempotencyKey string    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CreatePaymentRequest struct {
+	OrderID  *int64  `json:"order_id"`
+	Amount   float64 `json:"amount" binding:"required"`
+	Currency string  `json:"currency"`
+	// Списать сразу после авторизации
+	Capture bool `json:"capture"`
+}
+
+type PaymentAmountRequest struct {
+	// Пустая сумма означает полную сумму операции
+	Amount float64 `json:"amount"`
+}
+
+// PaymentWebhookEvent - событие от платежного провайдера
+type PaymentWebhookEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Reference string  `json:"reference"`
+		Amount    float64 `json:"amount"`
+		Reason    string  `json:"reason,omitempty"`
+	} `json:"data"`
+	Raw json.RawMessage `json:"-"`
+}
+
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventFailed     = "payment.failed"
+	PaymentEventVoided     = "payment.voided"
+	PaymentEventRefunded   = "payment.refunded"
+)
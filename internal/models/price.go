@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	PriceSourceInitial  = "initial"
+	PriceSourceManual   = "manual"
+	PriceSourceSchedule = "schedule"
+)
+
+// PriceChange - запись истории изменения цены продукта
+type PriceChange struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	OldPrice  *float64  `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+	Source    string    `json:"source"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ScheduledPrice - цена, действующая в интервале [StartsAt, EndsAt)
+type ScheduledPrice struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	Price     float64   `json:"price"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Started   bool      `json:"started"`
+	Ended     bool      `json:"ended"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateScheduledPriceRequest struct {
+	Price    float64   `json:"price" binding:"required"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+}
@@ -12,10 +12,28 @@ type Product struct {
 	Stock       int       `json:"stock" redis:"stock"`
 	Category    string    `json:"category" redis:"category"`
 	ImageURL    string    `json:"image_url" redis:"image_url"`
+	TaxClass    string    `json:"tax_class" redis:"tax_class"`
+	WeightKg    float64   `json:"weight_kg" redis:"weight_kg"`
+	LengthCm    float64   `json:"length_cm" redis:"length_cm"`
+	WidthCm     float64   `json:"width_cm" redis:"width_cm"`
+	HeightCm    float64   `json:"height_cm" redis:"height_cm"`
+	RatingAvg   float64   `json:"rating_avg" redis:"rating_avg"`
+	RatingCount int       `json:"rating_count" redis:"rating_count"`
 	CreatedAt   time.Time `json:"created_at" redis:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" redis:"updated_at"`
+
+	// Рассчитываются при чтении с учетом запланированных цен; price остается обычной ценой,
+	// чтобы клиент, отправивший прочитанный продукт обратно, не записал цену акции как обычную
+	EffectivePrice float64  `json:"effective_price" redis:"effective_price"`
+	CompareAtPrice *float64 `json:"compare_at_price,omitempty" redis:"compare_at_price"`
+	LowestPrice30d float64  `json:"lowest_price_30d" redis:"lowest_price_30d"`
 }
 
+const (
+	ProductSortNewest = "newest"
+	ProductSortRating = "rating"
+)
+
 type CreateProductRequest struct {
 	Name        string  `json:"name" binding:"required"`
 	Description string  `json:"description" binding:"required"`
@@ -23,13 +41,24 @@ type CreateProductRequest struct {
 	Stock       int     `json:"stock" binding:"required,min=0"`
 	Category    string  `json:"category" binding:"required"`
 	ImageURL    string  `json:"image_url"`
+	TaxClass    string  `json:"tax_class"`
+	WeightKg    float64 `json:"weight_kg" binding:"min=0"`
+	LengthCm    float64 `json:"length_cm" binding:"min=0"`
+	WidthCm     float64 `json:"width_cm" binding:"min=0"`
+	HeightCm    float64 `json:"height_cm" binding:"min=0"`
 }
 
+// UpdateProductRequest - частичное обновление: не переданные поля остаются без изменений
 type UpdateProductRequest struct {
-	Name        string  `json:"name,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Price       float64 `json:"price,omitempty"`
-	Stock       int     `json:"stock,omitempty" binding:"min=0"`
-	Category    string  `json:"category,omitempty"`
-	ImageURL    string  `json:"image_url,omitempty"`
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
+	Stock       *int     `json:"stock,omitempty" binding:"omitempty,min=0"`
+	Category    *string  `json:"category,omitempty"`
+	ImageURL    *string  `json:"image_url,omitempty"`
+	TaxClass    *string  `json:"tax_class,omitempty"`
+	WeightKg    *float64 `json:"weight_kg,omitempty"`
+	LengthCm    *float64 `json:"length_cm,omitempty"`
+	WidthCm     *float64 `json:"width_cm,omitempty"`
+	HeightCm    *float64 `json:"height_cm,omitempty"`
 }
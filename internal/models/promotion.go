@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	PromotionPercentage   = "percentage"
+	PromotionFixedAmount  = "fixed_amount"
+	PromotionBuyXGetY     = "buy_x_get_y"
+	PromotionFreeShipping = "free_shipping"
+)
+
+// Причины, по которым промокод не был применен
+const (
+	PromotionRejectNotFound      = "not_found"
+	PromotionRejectInactive      = "inactive"
+	PromotionRejectNotStarted    = "not_started"
+	PromotionRejectExpired       = "expired"
+	PromotionRejectMinSubtotal   = "min_subtotal_not_met"
+	PromotionRejectNotApplicable = "not_applicable"
+	PromotionRejectNotCombinable = "not_combinable"
+)
+
+// Promotion - автоматическая акция (без кода) или промокод.
+// Пустые ProductIDs и Categories означают, что акция действует на всю корзину.
+type Promotion struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Code        string     `json:"code,omitempty"`
+	Type        string     `json:"type"`
+	Value       float64    `json:"value"`
+	BuyQuantity int        `json:"buy_quantity,omitempty"`
+	GetQuantity int        `json:"get_quantity,omitempty"`
+	ProductIDs  []int64    `json:"product_ids"`
+	Categories  []string   `json:"categories"`
+	MinSubtotal float64    `json:"min_subtotal"`
+	Stackable   bool       `json:"stackable"`
+	Priority    int        `json:"priority"`
+	Active      bool       `json:"active"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// PromotionRequest - параметры акции. UsageLimit и PerCustomerLimit считаются по оформленным
+// заказам, которых в API пока нет, поэтому ненулевые значения отклоняются, а не игнорируются
+type PromotionRequest struct {
+	Name             string     `json:"name" binding:"required"`
+	Code             string     `json:"code"`
+	Type             string     `json:"type" binding:"required"`
+	Value            float64    `json:"value"`
+	BuyQuantity      int        `json:"buy_quantity"`
+	GetQuantity      int        `json:"get_quantity"`
+	ProductIDs       []int64    `json:"product_ids"`
+	Categories       []string   `json:"categories"`
+	MinSubtotal      float64    `json:"min_subtotal"`
+	UsageLimit       int        `json:"usage_limit"`
+	PerCustomerLimit int        `json:"per_customer_limit"`
+	Stackable        bool       `json:"stackable"`
+	Priority         int        `json:"priority"`
+	Active           *bool      `json:"active"`
+	StartsAt         *time.Time `json:"starts_at"`
+	EndsAt           *time.Time `json:"ends_at"`
+}
+
+type LineDiscount struct {
+	ProductID int64   `json:"product_id"`
+	Amount    float64 `json:"amount"`
+}
+
+type AppliedDiscount struct {
+	PromotionID int64          `json:"promotion_id"`
+	Name        string         `json:"name"`
+	Code        string         `json:"code,omitempty"`
+	Type        string         `json:"type"`
+	Amount      float64        `json:"amount"`
+	Lines       []LineDiscount `json:"lines,omitempty"`
+}
+
+type RejectedCode struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// DiscountBreakdown - результат применения акций к корзине
+type DiscountBreakdown struct {
+	Subtotal         float64           `json:"subtotal"`
+	ShippingCost     float64           `json:"shipping_cost"`
+	Discounts        []AppliedDiscount `json:"discounts"`
+	ItemsDiscount    float64           `json:"items_discount"`
+	ShippingDiscount float64           `json:"shipping_discount"`
+	TotalDiscount    float64           `json:"total_discount"`
+	Total            float64           `json:"total"`
+	RejectedCodes    []RejectedCode    `json:"rejected_codes,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	RelationCrossSell = "cross_sell"
+	RelationUpSell    = "up_sell"
+	RelationAccessory = "accessory"
+	// Рассчитанная похожесть по категории и описанию
+	RelationSimilar = "similar"
+)
+
+// ProductRelation - связь товаров, заданная вручную
+type ProductRelation struct {
+	ProductID        int64     `json:"product_id"`
+	RelatedProductID int64     `json:"related_product_id"`
+	Type             string    `json:"type"`
+	Position         int       `json:"position"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ProductRelationRequest struct {
+	RelatedProductID int64  `json:"related_product_id" binding:"required"`
+	Type             string `json:"type" binding:"required"`
+	Position         int    `json:"position"`
+}
+
+type ProductSimilarity struct {
+	ProductID        int64
+	SimilarProductID int64
+	Score            float64
+}
+
+type RelatedProduct struct {
+	Type    string   `json:"type"`
+	Score   float64  `json:"score,omitempty"`
+	Product *Product `json:"product"`
+}
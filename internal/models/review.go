@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+)
+
+type Review struct {
+	ID           int64      `json:"id"`
+	ProductID    int64      `json:"product_id"`
+	CustomerID   int64      `json:"customer_id"`
+	AuthorName   string     `json:"author_name"`
+	Rating       int        `json:"rating"`
+	Title        string     `json:"title"`
+	Body         string     `json:"body"`
+	Status       string     `json:"status"`
+	HelpfulCount int        `json:"helpful_count"`
+	Reply        string     `json:"reply,omitempty"`
+	RepliedAt    *time.Time `json:"replied_at,omitempty"`
+	ModeratedAt  *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+type ReviewReplyRequest struct {
+	// Пустой ответ удаляет ответ магазина
+	Reply string `json:"reply"`
+}
+
+type ReviewFilter struct {
+	ProductID *int64
+	Status    string
+	Sort      string
+	Limit     int
+	Offset    int
+}
+
+type ReviewPage struct {
+	Items  []*Review `json:"items"`
+	Total  int       `json:"total"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ShippingFlatRate      = "flat"
+	ShippingWeightBased   = "weight"
+	ShippingFreeThreshold = "free_threshold"
+)
+
+// ShippingZone объединяет страны и, опционально, шаблоны почтовых индексов.
+// Шаблон - маска с * и ? (например, "75*") или числовой диапазон ("10000..19999").
+type ShippingZone struct {
+	ID             int64             `json:"id"`
+	Name           string            `json:"name"`
+	Countries      []string          `json:"countries"`
+	PostalPatterns []string          `json:"postal_patterns"`
+	Priority       int               `json:"priority"`
+	Methods        []*ShippingMethod `json:"methods"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// WeightRate - стоимость доставки для веса до MaxWeightKg включительно
+type WeightRate struct {
+	MaxWeightKg float64 `json:"max_weight_kg"`
+	Cost        float64 `json:"cost"`
+}
+
+// ShippingMethod - способ доставки в зоне.
+// flat - фиксированная стоимость, weight - по весовым интервалам,
+// free_threshold - бесплатно при сумме корзины от Threshold.
+type ShippingMethod struct {
+	ID                int64        `json:"id"`
+	ZoneID            int64        `json:"zone_id"`
+	Name              string       `json:"name"`
+	Type              string       `json:"type"`
+	Cost              float64      `json:"cost"`
+	Threshold         float64      `json:"threshold,omitempty"`
+	WeightRates       []WeightRate `json:"weight_rates,omitempty"`
+	VolumetricDivisor int          `json:"volumetric_divisor,omitempty"`
+	Active            bool         `json:"active"`
+}
+
+type ShippingZoneRequest struct {
+	Name           string                  `json:"name" binding:"required"`
+	Countries      []string                `json:"countries" binding:"required"`
+	PostalPatterns []string                `json:"postal_patterns"`
+	Priority       int                     `json:"priority"`
+	Methods        []ShippingMethodRequest `json:"methods"`
+}
+
+// ShippingMethodRequest описывает способ доставки зоны. При обновлении зоны
+// способ с ID изменяется на месте, без ID - создается, отсутствующие в запросе удаляются.
+type ShippingMethodRequest struct {
+	ID                int64        `json:"id,omitempty"`
+	Name              string       `json:"name" binding:"required"`
+	Type              string       `json:"type" binding:"required"`
+	Cost              float64      `json:"cost"`
+	Threshold         float64      `json:"threshold"`
+	WeightRates       []WeightRate `json:"weight_rates"`
+	VolumetricDivisor int          `json:"volumetric_divisor"`
+	Active            *bool        `json:"active"`
+}
+
+// Shipment - данные корзины, необходимые для расчета доставки
+type Shipment struct {
+	Destination Destination `json:"destination"`
+	Subtotal    float64     `json:"subtotal"`
+	WeightKg    float64     `json:"weight_kg"`
+	VolumeCm3   float64     `json:"volume_cm3"`
+}
+
+type ShippingRate struct {
+	MethodID int64   `json:"method_id"`
+	Provider string  `json:"provider"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Cost     float64 `json:"cost"`
+}
+
+type ShippingRatesRequest struct {
+	Items       []CartItemRequest `json:"items" binding:"required"`
+	Destination Destination       `json:"destination" binding:"required"`
+}
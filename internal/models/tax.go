@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TaxClassStandard = "standard"
+	TaxClassShipping = "shipping"
+
+	TaxRoundingPerLine  = "line"
+	TaxRoundingPerOrder = "order"
+)
+
+// TaxRate - ставка налога в процентах. Пустой Region означает ставку для всей страны.
+type TaxRate struct {
+	ID        int64     `json:"id"`
+	Country   string    `json:"country"`
+	Region    string    `json:"region"`
+	TaxClass  string    `json:"tax_class"`
+	Rate      float64   `json:"rate"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TaxRateRequest struct {
+	Country  string  `json:"country" binding:"required"`
+	Region   string  `json:"region"`
+	TaxClass string  `json:"tax_class"`
+	Rate     float64 `json:"rate" binding:"required,min=0"`
+	Name     string  `json:"name"`
+}
+
+// Destination - адрес доставки, по которому определяются налоги и тарифы
+type Destination struct {
+	Country    string `json:"country"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+}
+
+type TaxableLine struct {
+	ProductID int64   `json:"product_id"`
+	TaxClass  string  `json:"tax_class"`
+	Amount    float64 `json:"amount"`
+}
+
+type TaxRequest struct {
+	Destination    Destination   `json:"destination"`
+	Lines          []TaxableLine `json:"lines"`
+	ShippingAmount float64       `json:"shipping_amount"`
+}
+
+// TaxLine - налог по одной позиции; для доставки ProductID равен 0
+type TaxLine struct {
+	ProductID   int64   `json:"product_id"`
+	TaxClass    string  `json:"tax_class"`
+	Rate        float64 `json:"rate"`
+	NetAmount   float64 `json:"net_amount"`
+	TaxAmount   float64 `json:"tax_amount"`
+	GrossAmount float64 `json:"gross_amount"`
+}
+
+type TaxResult struct {
+	PricesIncludeTax bool      `json:"prices_include_tax"`
+	Lines            []TaxLine `json:"lines"`
+	TotalNet         float64   `json:"total_net"`
+	TotalTax         float64   `json:"total_tax"`
+	TotalGross       float64   `json:"total_gross"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription - подписка внешней системы на доменные события.
+// Секрет возвращается только при создании.
+type WebhookSubscription struct {
+	ID                  int64     `json:"id"`
+	URL                 string    `json:"url"`
+	EventTypes          []string  `json:"event_types"`
+	Secret              string    `json:"secret,omitempty"`
+	Active              bool      `json:"active"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	DisabledReason      string    `json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	// Пустой секрет при создании генерируется автоматически, при обновлении - не меняется
+	Secret string `json:"secret"`
+	Active *bool  `json:"active"`
+}
+
+type WebhookDelivery struct {
+	ID               int64                     `json:"id"`
+	SubscriptionID   int64                     `json:"subscription_id"`
+	EventID          int64                     `json:"event_id"`
+	EventType        string                    `json:"event_type"`
+	Payload          json.RawMessage           `json:"payload"`
+	Status           string                    `json:"status"`
+	Attempts         int                       `json:"attempts"`
+	NextAttemptAt    time.Time                 `json:"next_attempt_at"`
+	LastResponseCode *int                      `json:"last_response_code,omitempty"`
+	LastError        string                    `json:"last_error,omitempty"`
+	DeliveredAt      *time.Time                `json:"delivered_at,omitempty"`
+	AttemptLog       []*WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+	CreatedAt        time.Time                 `json:"created_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID           int64     `json:"id"`
+	DeliveryID   int64     `json:"delivery_id"`
+	ResponseCode *int      `json:"response_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int       `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WebhookPayload - тело запроса, отправляемого подписчику
+type WebhookPayload struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Data          json.RawMessage `json:"data"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
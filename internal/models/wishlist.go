@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	WishlistNotificationBackInStock = "back_in_stock"
+	WishlistNotificationPriceDrop   = "price_drop"
+)
+
+type Wishlist struct {
+	ID         int64           `json:"id"`
+	CustomerID int64           `json:"-"`
+	Name       string          `json:"name"`
+	ShareToken *string         `json:"share_token,omitempty"`
+	Items      []*WishlistItem `json:"items"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+type WishlistItem struct {
+	ProductID int64 `json:"product_id"`
+	// Цена на момент добавления, для отображения снижения цены
+	AddedPrice float64   `json:"added_price"`
+	AddedAt    time.Time `json:"added_at"`
+	Product    *Product  `json:"product,omitempty"`
+}
+
+type WishlistRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type WishlistItemRequest struct {
+	ProductID int64 `json:"product_id" binding:"required"`
+}
+
+// WishlistNotification - уведомление о товаре из списка желаний
+type WishlistNotification struct {
+	ID         int64      `json:"id"`
+	CustomerID int64      `json:"-"`
+	ProductID  int64      `json:"product_id"`
+	Type       string     `json:"type"`
+	OldPrice   *float64   `json:"old_price,omitempty"`
+	NewPrice   *float64   `json:"new_price,omitempty"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
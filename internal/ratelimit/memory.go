@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит корзины в памяти процесса
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// Когда корзина заполнится и её можно удалить
+	full time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (*Result, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	rate := float64(limit.Limit) / float64(limit.Period)
+	b.tokens = min(float64(limit.Burst), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	result := NewResult(limit, b.tokens, allowed)
+	b.full = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// RunCleanup периодически удаляет заполненные корзины
+func (s *MemoryStore) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			if !b.full.After(now) {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
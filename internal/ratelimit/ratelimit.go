@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"shop-api/internal/metrics"
+)
+
+// Result - состояние корзины токенов после запроса
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Через сколько появится следующий токен; имеет смысл, если запрос отклонен
+	RetryAfter time.Duration
+	// Через сколько корзина заполнится полностью
+	ResetAfter time.Duration
+}
+
+// Store списывает токен из корзины key. Корзина пополняется на Limit токенов за Period
+// и вмещает не больше Burst токенов.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (*Result, error)
+}
+
+// NewResult вычисляет ответ по количеству токенов, оставшихся после списания
+func NewResult(limit Limit, tokens float64, allowed bool) *Result {
+	perToken := limit.Period / time.Duration(limit.Limit)
+	result := &Result{
+		Allowed:    allowed,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(limit.Burst) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return result
+}
+
+// Limiter ограничивает частоту запросов по правилам. Если основное хранилище недоступно,
+// используется резервное (в памяти процесса), и лимиты считаются отдельно на каждом экземпляре.
+type Limiter struct {
+	rules    atomic.Pointer[[]Rule]
+	apiKeys  atomic.Pointer[map[string]bool]
+	store    Store
+	fallback Store
+	logger   *slog.Logger
+}
+
+func New(rules []Rule, apiKeys []string, store, fallback Store, logger *slog.Logger) *Limiter {
+	l := &Limiter{store: store, fallback: fallback, logger: logger}
+	l.SetRules(rules)
+	l.SetAPIKeys(apiKeys)
+	return l
+}
+
+// SetRules заменяет правила; запросы, которые уже обрабатываются, дорабатывают по старым.
+// Без правил запросы не ограничиваются.
+func (l *Limiter) SetRules(rules []Rule) {
+	l.rules.Store(&rules)
+}
+
+// SetAPIKeys заменяет выданные ключи, к которым применяются правила с key=api_key
+func (l *Limiter) SetAPIKeys(keys []string) {
+	hashes := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		hashes[hashAPIKey(key)] = true
+	}
+	l.apiKeys.Store(&hashes)
+}
+
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, subject := l.match(r)
+		if rule == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		key := "ratelimit:" + rule.Name + ":" + subject
+		result, err := l.store.Take(ctx, key, rule.Limit)
+		if err != nil {
+			l.logger.DebugContext(ctx, "rate limit store unavailable, using fallback", "error", err)
+			if result, err = l.fallback.Take(ctx, key, rule.Limit); err != nil {
+				// Без хранилища запрос пропускается: лимит не должен ломать API
+				l.logger.ErrorContext(ctx, "rate limit fallback error", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", rule.Limit.Limit, ceilSeconds(rule.Limit.Period), rule.Limit.Burst))
+		header.Set("RateLimit-Limit", strconv.Itoa(rule.Limit.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(rule.Name).Inc()
+			l.logger.InfoContext(ctx, "rate limit exceeded", "rule", rule.Name, "subject", subject)
+			header.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// match возвращает первое подходящее правило, для которого определен клиент
+func (l *Limiter) match(r *http.Request) (*Rule, string) {
+	// Preflight-запросы CORS не ограничиваются
+	if r.Method == http.MethodOptions {
+		return nil, ""
+	}
+	rules := *l.rules.Load()
+	apiKeys := *l.apiKeys.Load()
+	for i := range rules {
+		if !rules[i].matches(r) {
+			continue
+		}
+		if subject := rules[i].subject(r, apiKeys); subject != "" {
+			return &rules[i], subject
+		}
+	}
+	return nil, ""
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,204 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shop-api/internal/auth"
+	"shop-api/internal/requestctx"
+)
+
+// Чем идентифицируется клиент в правиле
+const (
+	KeyIP     = "ip"
+	KeyAPIKey = "api_key"
+	KeyUser   = "user"
+	// KeyAuto - ID пользователя, если он аутентифицирован, иначе IP
+	KeyAuto = "auto"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// Limit - не больше Limit запросов за Period с допустимым всплеском до Burst запросов
+type Limit struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Rule задает лимит для запросов, подходящих по методу и пути
+type Rule struct {
+	Name    string
+	Methods []string
+	// Путь по сегментам: {param} - один любой сегмент, * в конце - любой остаток пути
+	Path string
+	// AuthFailed - правило только для запросов с неверным токеном
+	AuthFailed bool
+	Key        string
+	Limit      Limit
+}
+
+// ParseRules разбирает правила, разделенные ";". Правило - пары key=value через пробел:
+//
+//	name=payments methods=POST path=/api/payments limit=20/1m burst=5 key=user
+//
+// methods, burst и key необязательны (по умолчанию все методы, burst=limit, key=auto);
+// auth=failed ограничивает правило запросами с неверным токеном.
+// Применяется первое подходящее правило, для которого определен клиент: правило с key=user
+// пропускается для анонимного запроса, с key=api_key - для запроса без выданного ключа.
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		rule, err := parseRule(part)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %q: %w", part, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseRule(s string) (Rule, error) {
+	rule := Rule{Key: KeyAuto}
+	for _, field := range strings.Fields(s) {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return rule, fmt.Errorf("invalid field %q", field)
+		}
+		switch name {
+		case "name":
+			rule.Name = value
+		case "methods":
+			for _, m := range strings.Split(value, ",") {
+				rule.Methods = append(rule.Methods, strings.ToUpper(m))
+			}
+		case "path":
+			rule.Path = value
+		case "auth":
+			if value != "failed" {
+				return rule, fmt.Errorf("unknown auth %q", value)
+			}
+			rule.AuthFailed = true
+		case "limit":
+			count, period, ok := strings.Cut(value, "/")
+			if !ok {
+				return rule, fmt.Errorf("limit must be <count>/<period>")
+			}
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid limit count %q", count)
+			}
+			d, err := time.ParseDuration(period)
+			if err != nil || d <= 0 {
+				return rule, fmt.Errorf("invalid limit period %q", period)
+			}
+			rule.Limit.Limit, rule.Limit.Period = n, d
+		case "burst":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid burst %q", value)
+			}
+			rule.Limit.Burst = n
+		case "key":
+			switch value {
+			case KeyIP, KeyAPIKey, KeyUser, KeyAuto:
+				rule.Key = value
+			default:
+				return rule, fmt.Errorf("unknown key %q", value)
+			}
+		default:
+			return rule, fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	if rule.Name == "" || rule.Path == "" || rule.Limit.Limit == 0 {
+		return rule, fmt.Errorf("name, path and limit are required")
+	}
+	if rule.Limit.Burst == 0 {
+		rule.Limit.Burst = rule.Limit.Limit
+	}
+	return rule, nil
+}
+
+func (rule *Rule) matches(r *http.Request) bool {
+	if rule.AuthFailed && !auth.Failed(r.Context()) {
+		return false
+	}
+	if len(rule.Methods) > 0 {
+		found := false
+		for _, m := range rule.Methods {
+			if m == r.Method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return matchPath(rule.Path, r.URL.Path)
+}
+
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range patternSegments {
+		if seg == "*" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			continue
+		}
+		if seg != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+// subject возвращает идентификатор клиента для правила. Пустая строка - правило не применяется:
+// key=user для анонимного запроса или key=api_key для запроса без выданного ключа.
+func (rule *Rule) subject(r *http.Request, apiKeys map[string]bool) string {
+	identity, authenticated := auth.IdentityFromContext(r.Context())
+	switch rule.Key {
+	case KeyUser:
+		if !authenticated {
+			return ""
+		}
+		return "user:" + strconv.FormatInt(identity.UserID, 10)
+	case KeyAPIKey:
+		key := r.Header.Get(apiKeyHeader)
+		if key == "" {
+			return ""
+		}
+		// Неизвестный ключ не получает своей корзины, иначе лимит обходится сменой ключа.
+		// Сам ключ в хранилище не попадает.
+		hash := hashAPIKey(key)
+		if !apiKeys[hash] {
+			return ""
+		}
+		return "key:" + hash[:16]
+	case KeyAuto:
+		if authenticated {
+			return "user:" + strconv.FormatInt(identity.UserID, 10)
+		}
+	}
+	return "ip:" + requestctx.ClientIP(r.Context())
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
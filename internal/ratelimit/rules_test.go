@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"shop-api/internal/auth"
+	"shop-api/internal/requestctx"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Rule
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			input: "name=default path=/api/* limit=600/1m",
+			want: []Rule{{Name: "default", Path: "/api/*", Key: KeyAuto,
+				Limit: Limit{Limit: 600, Period: time.Minute, Burst: 600}}},
+		},
+		{
+			name:  "all fields",
+			input: " name=payments methods=post,PUT path=/api/payments limit=20/30s burst=5 key=user ; ",
+			want: []Rule{{Name: "payments", Methods: []string{"POST", "PUT"}, Path: "/api/payments", Key: KeyUser,
+				Limit: Limit{Limit: 20, Period: 30 * time.Second, Burst: 5}}},
+		},
+		{
+			name:  "auth failed",
+			input: "name=auth auth=failed path=/api/* limit=10/1m burst=5 key=ip",
+			want: []Rule{{Name: "auth", Path: "/api/*", AuthFailed: true, Key: KeyIP,
+				Limit: Limit{Limit: 10, Period: time.Minute, Burst: 5}}},
+		},
+		{
+			name:  "several rules keep order",
+			input: "name=a path=/api/a limit=1/1s;name=b path=/api/* limit=2/1s key=api_key",
+			want: []Rule{
+				{Name: "a", Path: "/api/a", Key: KeyAuto, Limit: Limit{Limit: 1, Period: time.Second, Burst: 1}},
+				{Name: "b", Path: "/api/*", Key: KeyAPIKey, Limit: Limit{Limit: 2, Period: time.Second, Burst: 2}},
+			},
+		},
+		{name: "empty", input: " ; ", want: nil},
+		{name: "missing limit", input: "name=a path=/api/*", wantErr: true},
+		{name: "missing name", input: "path=/api/* limit=1/1s", wantErr: true},
+		{name: "limit without period", input: "name=a path=/api/* limit=10", wantErr: true},
+		{name: "zero limit", input: "name=a path=/api/* limit=0/1m", wantErr: true},
+		{name: "bad period", input: "name=a path=/api/* limit=10/soon", wantErr: true},
+		{name: "negative period", input: "name=a path=/api/* limit=10/-1m", wantErr: true},
+		{name: "zero burst", input: "name=a path=/api/* limit=10/1m burst=0", wantErr: true},
+		{name: "unknown key", input: "name=a path=/api/* limit=10/1m key=cookie", wantErr: true},
+		{name: "unknown auth", input: "name=a path=/api/* limit=10/1m auth=ok", wantErr: true},
+		{name: "unknown field", input: "name=a path=/api/* limit=10/1m window=1m", wantErr: true},
+		{name: "field without value", input: "name=a path=/api/* limit=10/1m burst=", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/api/*", "/api/products/1", true},
+		{"/api/*", "/api", true},
+		{"/api/products/{id}", "/api/products/1", true},
+		{"/api/products/{id}", "/api/products/1/reviews", false},
+		{"/api/payments", "/api/payments/", true},
+		{"/api/payments", "/api/payment", false},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterMatch(t *testing.T) {
+	rules, err := ParseRules("name=auth auth=failed path=/api/* limit=10/1m key=ip;" +
+		"name=partner path=/api/* limit=100/1m key=api_key;" +
+		"name=user path=/api/* limit=50/1m key=user;" +
+		"name=default path=/api/* limit=20/1m key=ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := New(rules, []string{"issued-key"}, NewMemoryStore(), NewMemoryStore(), slog.New(slog.DiscardHandler))
+
+	// Authenticate помечает неверный токен, не отклоняя запрос
+	var failedCtx context.Context
+	withBadToken := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+	withBadToken.Header.Set("Authorization", "Bearer garbage")
+	verifier := auth.NewVerifier("0123456789abcdef0123456789abcdef", "", "")
+	auth.Authenticate(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failedCtx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), withBadToken)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		apiKey      string
+		wantRule    string
+		wantSubject string
+	}{
+		{name: "anonymous falls back to ip", ctx: context.Background(), wantRule: "default", wantSubject: "ip:192.0.2.1"},
+		{name: "user", ctx: auth.WithIdentity(context.Background(), &auth.Identity{UserID: 7}), wantRule: "user", wantSubject: "user:7"},
+		{name: "unknown api key", ctx: context.Background(), apiKey: "random", wantRule: "default", wantSubject: "ip:192.0.2.1"},
+		{name: "issued api key", ctx: context.Background(), apiKey: "issued-key", wantRule: "partner", wantSubject: "key:" + hashAPIKey("issued-key")[:16]},
+		{name: "failed auth", ctx: failedCtx, wantRule: "auth", wantSubject: "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/products", nil).WithContext(tt.ctx)
+			if tt.apiKey != "" {
+				r.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			requestctx.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { r = req })).
+				ServeHTTP(httptest.NewRecorder(), r)
+
+			rule, subject := limiter.match(r)
+			if rule == nil {
+				t.Fatal("no rule matched")
+			}
+			if rule.Name != tt.wantRule || subject != tt.wantSubject {
+				t.Errorf("match() = %s %s, want %s %s", rule.Name, subject, tt.wantRule, tt.wantSubject)
+			}
+		})
+	}
+}
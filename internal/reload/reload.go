@@ -0,0 +1,163 @@
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"shop-api/internal/metrics"
+	"shop-api/pkg/config"
+)
+
+// Reloader хранит текущую конфигурацию и перечитывает её по SIGHUP или при изменении файла.
+// Применяются только поля с тегом reload; об изменении остальных пишется предупреждение,
+// они вступают в силу после перезапуска. Если новая конфигурация не проходит проверку,
+// остается прежняя.
+type Reloader struct {
+	args   []string
+	logger *slog.Logger
+
+	current atomic.Pointer[config.Config]
+
+	// Перечитывания выполняются по одному
+	mu          sync.Mutex
+	checks      []func(*config.Config) error
+	subscribers []func(old, cfg *config.Config)
+	fileSum     [sha256.Size]byte
+}
+
+// New создает Reloader для конфигурации, загруженной из args
+func New(cfg *config.Config, args []string, logger *slog.Logger) *Reloader {
+	r := &Reloader{args: args, logger: logger}
+	r.current.Store(cfg)
+	if cfg.File != "" {
+		r.fileSum, _ = fileSum(cfg.File)
+	}
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	return r
+}
+
+// Config возвращает текущую конфигурацию; её нельзя изменять
+func (r *Reloader) Config() *config.Config {
+	return r.current.Load()
+}
+
+// Check добавляет проверку, которую новая конфигурация должна пройти перед применением,
+// например разбор значений, которые проверяет сам компонент. Вызывается до Run.
+func (r *Reloader) Check(fn func(*config.Config) error) {
+	r.checks = append(r.checks, fn)
+}
+
+// Subscribe добавляет обработчик, который получает прежнюю и новую конфигурацию после применения.
+// Вызывается до Run.
+func (r *Reloader) Subscribe(fn func(old, cfg *config.Config)) {
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload перечитывает конфигурацию; trigger попадает в лог
+func (r *Reloader) Reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.current.Load()
+	if old.File != "" {
+		// Неверный файл не перечитывается повторно, пока его не исправят
+		if sum, err := fileSum(old.File); err == nil {
+			r.fileSum = sum
+		}
+	}
+	next, err := config.Load(r.args, io.Discard)
+	var cfg *config.Config
+	var applied, restart []string
+	if err == nil {
+		cfg, applied, restart = old.Reloadable(next)
+		// Проверяется именно применяемая конфигурация: новые значения вместе с прежними,
+		// требующими перезапуска
+		errs := []error{cfg.Validate()}
+		for _, check := range r.checks {
+			errs = append(errs, check(cfg))
+		}
+		err = errors.Join(errs...)
+	}
+	if err != nil {
+		metrics.ConfigReloads.WithLabelValues("failure").Inc()
+		metrics.ConfigLastReloadSuccessful.Set(0)
+		r.logger.Error("configuration reload failed, keeping previous configuration", "trigger", trigger, "error", err)
+		return err
+	}
+
+	if len(restart) > 0 {
+		r.logger.Warn("configuration changes require restart", "trigger", trigger, "keys", restart)
+	}
+	r.current.Store(cfg)
+	for _, fn := range r.subscribers {
+		fn(old, cfg)
+	}
+
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	r.logger.Info("configuration reloaded", "trigger", trigger, "applied", applied)
+	return nil
+}
+
+// Run перечитывает конфигурацию по SIGHUP и, если задан файл, при изменении его содержимого,
+// которое проверяется раз в interval
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var ticks <-chan time.Time
+	if file := r.Config().File; file != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.Reload("signal")
+		case <-ticks:
+			if r.fileChanged() {
+				r.Reload("file")
+			}
+		}
+	}
+}
+
+// fileChanged сравнивает содержимое файла с прочитанным в прошлый раз. Сравнивается содержимое,
+// а не время изменения, чтобы замечать подмену файла через символическую ссылку (ConfigMap).
+func (r *Reloader) fileChanged() bool {
+	file := r.Config().File
+	sum, err := fileSum(file)
+	if err != nil {
+		// Файл могут заменять в этот момент; проверим в следующий раз
+		r.logger.Debug("unable to read config file", "file", file, "error", err)
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sum != r.fileSum
+}
+
+func fileSum(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
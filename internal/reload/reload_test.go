@@ -0,0 +1,128 @@
+package reload
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shop-api/pkg/config"
+)
+
+const baseConfig = `
+server:
+  port: 8080
+logging:
+  level: info
+auth:
+  jwt_secret: 0123456789abcdef0123456789abcdef
+`
+
+// newReloader загружает конфигурацию из временного файла; файл можно переписать через write
+func newReloader(t *testing.T) (r *Reloader, write func(string), logs *bytes.Buffer) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write = func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(baseConfig)
+
+	args := []string{"-config", path}
+	cfg, err := config.Load(args, io.Discard)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	logs = &bytes.Buffer{}
+	return New(cfg, args, slog.New(slog.NewTextHandler(logs, nil))), write, logs
+}
+
+func TestReloadAppliesReloadableKeys(t *testing.T) {
+	r, write, logs := newReloader(t)
+	var notified *config.Config
+	r.Subscribe(func(old, cfg *config.Config) { notified = cfg })
+
+	write(strings.Replace(strings.Replace(baseConfig, "level: info", "level: debug", 1), "port: 8080", "port: 9090", 1))
+	if err := r.Reload("test"); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	cfg := r.Config()
+	if cfg.Logging.Level != "debug" {
+		t.Errorf("logging.level = %q, want debug", cfg.Logging.Level)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("server.port = %d, want 8080 until restart", cfg.Server.Port)
+	}
+	if notified != cfg {
+		t.Error("subscriber did not receive the applied configuration")
+	}
+	if !strings.Contains(logs.String(), "configuration changes require restart") || !strings.Contains(logs.String(), "server.port") {
+		t.Errorf("restart-only key not reported, logs:\n%s", logs)
+	}
+}
+
+func TestReloadKeepsPreviousConfigOnError(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		check func(*config.Config) error
+	}{
+		{name: "unparsable file", file: "logging: [\n"},
+		{name: "unknown key", file: baseConfig + "unknown: 1\n"},
+		{name: "invalid value", file: strings.Replace(baseConfig, "level: info", "level: loud", 1)},
+		{
+			name:  "component check",
+			file:  strings.Replace(baseConfig, "level: info", "level: debug", 1),
+			check: func(*config.Config) error { return errors.New("rejected") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, write, _ := newReloader(t)
+			if tt.check != nil {
+				r.Check(tt.check)
+			}
+			called := false
+			r.Subscribe(func(old, cfg *config.Config) { called = true })
+			before := r.Config()
+
+			write(tt.file)
+			if err := r.Reload("test"); err == nil {
+				t.Fatal("Reload succeeded, want error")
+			}
+			if r.Config() != before {
+				t.Error("configuration replaced after failed reload")
+			}
+			if called {
+				t.Error("subscriber called after failed reload")
+			}
+		})
+	}
+}
+
+func TestReloadValidatesMergedConfig(t *testing.T) {
+	r, write, _ := newReloader(t)
+
+	// Прежнее значение, требующее перезапуска, недопустимо; новый файл проходит проверку сам по себе,
+	// но применяемая конфигурация сохранила бы прежнее значение
+	invalid := *r.Config()
+	invalid.Server.Port = 0
+	r.current.Store(&invalid)
+
+	write(strings.Replace(baseConfig, "level: info", "level: debug", 1))
+	err := r.Reload("test")
+	if err == nil || !strings.Contains(err.Error(), "server.port") {
+		t.Fatalf("Reload error = %v, want server.port validation error", err)
+	}
+	if r.Config() != &invalid {
+		t.Error("configuration replaced after failed reload")
+	}
+}
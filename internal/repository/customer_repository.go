@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shop-api/internal/models"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrCustomerExists   = errors.New("customer with this email already exists")
+	ErrAddressNotFound  = errors.New("address not found")
+)
+
+type CustomerRepository interface {
+	GetByID(ctx context.Context, id int64) (*models.Customer, error)
+	List(ctx context.Context, filter models.CustomerFilter) ([]*models.Customer, int, error)
+	// Upsert создает профиль при первом обращении или обновляет существующий.
+	// Email берется из токена; токен без email не затирает сохраненный
+	Upsert(ctx context.Context, customer *models.Customer) error
+	SetBlocked(ctx context.Context, id int64, blocked bool) (*models.Customer, error)
+
+	GetAddresses(ctx context.Context, customerID int64) ([]*models.Address, error)
+	GetAddress(ctx context.Context, customerID, id int64) (*models.Address, error)
+	CreateAddress(ctx context.Context, address *models.Address) error
+	UpdateAddress(ctx context.Context, address *models.Address) error
+	DeleteAddress(ctx context.Context, customerID, id int64) error
+	// ClearDefaultAddress снимает отметку "по умолчанию" с адресов клиента данного типа
+	ClearDefaultAddress(ctx context.Context, customerID int64, addressType string) error
+	// PromoteDefaultAddress назначает адресом по умолчанию самый новый адрес типа, если такого нет
+	PromoteDefaultAddress(ctx context.Context, customerID int64, addressType string) error
+}
+
+// PostgresCustomerRepository реализует интерфейс CustomerRepository
+type PostgresCustomerRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomerRepository(db *pgxpool.Pool) CustomerRepository {
+	return &PostgresCustomerRepository{db: db}
+}
+
+const (
+	customerColumns = `id, email, first_name, last_name, phone, blocked, blocked_at, created_at, updated_at`
+	addressColumns  = `id, customer_id, type, is_default, first_name, last_name, line1, line2, city, region,
+		postal_code, country, phone, created_at, updated_at`
+)
+
+func (r *PostgresCustomerRepository) GetByID(ctx context.Context, id int64) (*models.Customer, error) {
+	customer, err := scanCustomer(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+customerColumns+` FROM customers WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, err
+}
+
+func (r *PostgresCustomerRepository) List(ctx context.Context, filter models.CustomerFilter) ([]*models.Customer, int, error) {
+	var conditions []string
+	var args []any
+
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		conditions = append(conditions, fmt.Sprintf(
+			"(email ILIKE $%[1]d OR first_name ILIKE $%[1]d OR last_name ILIKE $%[1]d OR phone ILIKE $%[1]d)", len(args)))
+	}
+	if filter.Blocked != nil {
+		args = append(args, *filter.Blocked)
+		conditions = append(conditions, fmt.Sprintf("blocked = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM customers`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+customerColumns+` FROM customers`+where+
+			fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	customers := []*models.Customer{}
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		customers = append(customers, customer)
+	}
+	return customers, total, rows.Err()
+}
+
+func (r *PostgresCustomerRepository) Upsert(ctx context.Context, c *models.Customer) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO customers (id, email, first_name, last_name, phone)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (id) DO UPDATE
+		 SET email = COALESCE(NULLIF(EXCLUDED.email, ''), customers.email),
+		     first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name, phone = EXCLUDED.phone, updated_at = NOW()
+		 RETURNING `+customerColumns,
+		c.ID, c.Email, c.FirstName, c.LastName, c.Phone).
+		Scan(&c.ID, &c.Email, &c.FirstName, &c.LastName, &c.Phone, &c.Blocked, &c.BlockedAt, &c.CreatedAt, &c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrCustomerExists
+	}
+	return err
+}
+
+func (r *PostgresCustomerRepository) SetBlocked(ctx context.Context, id int64, blocked bool) (*models.Customer, error) {
+	customer, err := scanCustomer(conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE customers
+		 SET blocked = $1, blocked_at = CASE WHEN $1 THEN NOW() END, updated_at = NOW()
+		 WHERE id = $2
+		 RETURNING `+customerColumns,
+		blocked, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, err
+}
+
+func (r *PostgresCustomerRepository) GetAddresses(ctx context.Context, customerID int64) ([]*models.Address, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+addressColumns+` FROM customer_addresses
+		 WHERE customer_id = $1
+		 ORDER BY type, is_default DESC, created_at DESC`,
+		customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []*models.Address{}
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+func (r *PostgresCustomerRepository) GetAddress(ctx context.Context, customerID, id int64) (*models.Address, error) {
+	address, err := scanAddress(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+addressColumns+` FROM customer_addresses WHERE id = $1 AND customer_id = $2`,
+		id, customerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	return address, err
+}
+
+func (r *PostgresCustomerRepository) CreateAddress(ctx context.Context, a *models.Address) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO customer_addresses (customer_id, type, is_default, first_name, last_name, line1, line2,
+		     city, region, postal_code, country, phone)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING id, created_at, updated_at`,
+		a.CustomerID, a.Type, a.IsDefault, a.FirstName, a.LastName, a.Line1, a.Line2,
+		a.City, a.Region, a.PostalCode, a.Country, a.Phone).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+}
+
+func (r *PostgresCustomerRepository) UpdateAddress(ctx context.Context, a *models.Address) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE customer_addresses
+		 SET type = $1, is_default = $2, first_name = $3, last_name = $4, line1 = $5, line2 = $6,
+		     city = $7, region = $8, postal_code = $9, country = $10, phone = $11, updated_at = NOW()
+		 WHERE id = $12 AND customer_id = $13
+		 RETURNING created_at, updated_at`,
+		a.Type, a.IsDefault, a.FirstName, a.LastName, a.Line1, a.Line2,
+		a.City, a.Region, a.PostalCode, a.Country, a.Phone, a.ID, a.CustomerID).
+		Scan(&a.CreatedAt, &a.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrAddressNotFound
+	}
+	return err
+}
+
+func (r *PostgresCustomerRepository) DeleteAddress(ctx context.Context, customerID, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		"DELETE FROM customer_addresses WHERE id = $1 AND customer_id = $2", id, customerID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+func (r *PostgresCustomerRepository) ClearDefaultAddress(ctx context.Context, customerID int64, addressType string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE customer_addresses SET is_default = FALSE
+		 WHERE customer_id = $1 AND type = $2 AND is_default`,
+		customerID, addressType)
+	return err
+}
+
+func (r *PostgresCustomerRepository) PromoteDefaultAddress(ctx context.Context, customerID int64, addressType string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE customer_addresses SET is_default = TRUE
+		 WHERE id = (
+		     SELECT id FROM customer_addresses
+		     WHERE customer_id = $1 AND type = $2
+		     ORDER BY created_at DESC, id DESC
+		     LIMIT 1
+		 ) AND NOT EXISTS (
+		     SELECT 1 FROM customer_addresses WHERE customer_id = $1 AND type = $2 AND is_default
+		 )`,
+		customerID, addressType)
+	return err
+}
+
+func scanCustomer(row pgx.Row) (*models.Customer, error) {
+	var c models.Customer
+	err := row.Scan(&c.ID, &c.Email, &c.FirstName, &c.LastName, &c.Phone, &c.Blocked, &c.BlockedAt, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanAddress(row pgx.Row) (*models.Address, error) {
+	var a models.Address
+	err := row.Scan(&a.ID, &a.CustomerID, &a.Type, &a.IsDefault, &a.FirstName, &a.LastName, &a.Line1, &a.Line2,
+		&a.City, &a.Region, &a.PostalCode, &a.Country, &a.Phone, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
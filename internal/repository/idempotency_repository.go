@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository хранит ответы на запросы с ключом идемпотентности в Postgres
+type IdempotencyRepository interface {
+	// Begin сохраняет незавершенный запрос, если ключ свободен или его срок истек.
+	// Если ключ занят, возвращается существующая запись и false.
+	Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, record *models.IdempotencyRecord, retention time.Duration) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// PostgresIdempotencyRepository реализует интерфейс IdempotencyRepository
+type PostgresIdempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) IdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+func (r *PostgresIdempotencyRepository) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*models.IdempotencyRecord, bool, error) {
+	var record *models.IdempotencyRecord
+	var created bool
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND expires_at < NOW()`, key); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(ctx,
+			`INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+			 VALUES ($1, $2, NOW() + $3::INTERVAL)
+			 ON CONFLICT (key) DO NOTHING`,
+			key, fingerprint, lockTTL)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 1 {
+			created = true
+			record = &models.IdempotencyRecord{Fingerprint: fingerprint}
+			return nil
+		}
+
+		record = &models.IdempotencyRecord{}
+		return tx.QueryRow(ctx,
+			`SELECT fingerprint, completed, status_code, header, body FROM idempotency_keys WHERE key = $1`,
+			key).Scan(&record.Fingerprint, &record.Completed, &record.StatusCode, &record.Header, &record.Body)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Параллельный запрос успел освободить ключ, клиент может повторить
+		return &models.IdempotencyRecord{Fingerprint: fingerprint}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return record, created, nil
+}
+
+func (r *PostgresIdempotencyRepository) Complete(ctx context.Context, key string, record *models.IdempotencyRecord, retention time.Duration) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE idempotency_keys
+		 SET completed = TRUE, status_code = $2, header = $3, body = $4, expires_at = NOW() + $5::INTERVAL
+		 WHERE key = $1`,
+		key, record.StatusCode, record.Header, record.Body, retention)
+	return err
+}
+
+func (r *PostgresIdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND NOT completed`, key)
+	return err
+}
+
+func (r *PostgresIdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
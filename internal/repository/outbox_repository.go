@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"shop-api/internal/models"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, event *models.Event) error
+	// Claim захватывает до limit готовых к публикации событий на время lease.
+	// Из каждого агрегата берется только самое раннее неопубликованное событие,
+	// поэтому порядок внутри агрегата сохраняется и при нескольких экземплярах.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.Event, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresOutboxRepository реализует интерфейс OutboxRepository
+type PostgresOutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) OutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) Create(ctx context.Context, event *models.Event) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO outbox_events (aggregate_type, aggregate_id, type, payload)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		event.AggregateType, event.AggregateID, event.Type, event.Payload).
+		Scan(&event.ID, &event.CreatedAt)
+}
+
+func (r *PostgresOutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.Event, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`UPDATE outbox_events SET locked_until = NOW() + $2::INTERVAL
+		 WHERE id IN (
+		     SELECT e.id FROM outbox_events e
+		     WHERE e.published_at IS NULL
+		       AND e.next_attempt_at <= NOW()
+		       AND (e.locked_until IS NULL OR e.locked_until < NOW())
+		       AND NOT EXISTS (
+		           SELECT 1 FROM outbox_events p
+		           WHERE p.aggregate_type = e.aggregate_type
+		             AND p.aggregate_id = e.aggregate_id
+		             AND p.published_at IS NULL
+		             AND p.id < e.id)
+		     ORDER BY e.id
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING id, aggregate_type, aggregate_id, type, payload, attempts, created_at`,
+		limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*models.Event{}
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.Type, &e.Payload, &e.Attempts, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING не гарантирует порядок строк
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox_events SET published_at = NOW(), locked_until = NULL, last_error = '' WHERE id = $1`,
+		id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox_events
+		 SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3, locked_until = NULL
+		 WHERE id = $1`,
+		id, nextAttemptAt, lastError)
+	return err
+}
+
+func (r *PostgresOutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < $1`,
+		before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
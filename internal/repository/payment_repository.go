@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrPaymentNotFound = errors.New("payment not found")
+
+type PaymentRepository interface {
+	// CreateOrGet создает платеж или возвращает существующий с тем же ключом идемпотентности.
+	// created равен false, если платеж уже существовал.
+	CreateOrGet(ctx context.Context, payment *models.Payment) (created bool, err error)
+	GetByID(ctx context.Context, id int64) (*models.Payment, error)
+	// Lock возвращает платеж, блокируя строку до конца транзакции
+	Lock(ctx context.Context, id int64) (*models.Payment, error)
+	LockByProviderRef(ctx context.Context, provider, ref string) (*models.Payment, error)
+	Update(ctx context.Context, payment *models.Payment) error
+	AddAttempt(ctx context.Context, attempt *models.PaymentAttempt) error
+	GetAttempts(ctx context.Context, paymentID int64) ([]models.PaymentAttempt, error)
+	// HasSucceededAttempt сообщает, выполнялась ли уже успешно операция с этим ключом идемпотентности
+	HasSucceededAttempt(ctx context.Context, idempotencyKey string) (bool, error)
+	// SaveWebhookEvent сохраняет событие; false означает, что событие уже обработано
+	SaveWebhookEvent(ctx context.Context, provider string, event *models.PaymentWebhookEvent) (bool, error)
+}
+
+// PostgresPaymentRepository реализует интерфейс PaymentRepository
+type PostgresPaymentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRepository(db *pgxpool.Pool) PaymentRepository {
+	return &PostgresPaymentRepository{db: db}
+}
+
+const paymentColumns = `id, order_id, amount, currency, status, provider, provider_ref, idempotency_key,
+	captured_amount, refunded_amount, failure_reason, created_at, updated_at`
+
+func (r *PostgresPaymentRepository) CreateOrGet(ctx context.Context, p *models.Payment) (bool, error) {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO payments (order_id, amount, currency, status, provider, idempotency_key)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (idempotency_key) DO NOTHING
+		 RETURNING `+paymentColumns,
+		p.OrderID, p.Amount, p.Currency, p.Status, p.Provider, p.IdempotencyKey).
+		Scan(paymentFields(p)...)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+
+	err = conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+paymentColumns+` FROM payments WHERE idempotency_key = $1`, p.IdempotencyKey).
+		Scan(paymentFields(p)...)
+	return false, err
+}
+
+func (r *PostgresPaymentRepository) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	return r.get(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id)
+}
+
+func (r *PostgresPaymentRepository) Lock(ctx context.Context, id int64) (*models.Payment, error) {
+	return r.get(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *PostgresPaymentRepository) LockByProviderRef(ctx context.Context, provider, ref string) (*models.Payment, error) {
+	return r.get(ctx,
+		`SELECT `+paymentColumns+` FROM payments WHERE provider = $1 AND provider_ref = $2 FOR UPDATE`,
+		provider, ref)
+}
+
+func (r *PostgresPaymentRepository) Update(ctx context.Context, p *models.Payment) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE payments
+		 SET status = $1, provider_ref = $2, captured_amount = $3, refunded_amount = $4, failure_reason = $5,
+		     updated_at = NOW()
+		 WHERE id = $6
+		 RETURNING updated_at`,
+		p.Status, p.ProviderRef, p.CapturedAmount, p.RefundedAmount, p.FailureReason, p.ID).
+		Scan(&p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPaymentNotFound
+	}
+	return err
+}
+
+func (r *PostgresPaymentRepository) AddAttempt(ctx context.Context, a *models.PaymentAttempt) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO payment_attempts (payment_id, operation, amount, success, provider_ref, error, idempotency_key)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at`,
+		a.PaymentID, a.Operation, a.Amount, a.Success, a.ProviderRef, a.Error, a.IdempotencyKey).
+		Scan(&a.ID, &a.CreatedAt)
+}
+
+func (r *PostgresPaymentRepository) GetAttempts(ctx context.Context, paymentID int64) ([]models.PaymentAttempt, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, payment_id, operation, amount, success, provider_ref, error, created_at
+		 FROM payment_attempts
+		 WHERE payment_id = $1
+		 ORDER BY created_at, id`,
+		paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []models.PaymentAttempt
+	for rows.Next() {
+		var a models.PaymentAttempt
+		if err := rows.Scan(&a.ID, &a.PaymentID, &a.Operation, &a.Amount, &a.Success, &a.ProviderRef, &a.Error, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+func (r *PostgresPaymentRepository) HasSucceededAttempt(ctx context.Context, idempotencyKey string) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM payment_attempts WHERE idempotency_key = $1 AND success)`,
+		idempotencyKey).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresPaymentRepository) SaveWebhookEvent(ctx context.Context, provider string, event *models.PaymentWebhookEvent) (bool, error) {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO payment_webhook_events (event_id, provider, type, payload)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (event_id) DO NOTHING`,
+		event.ID, provider, event.Type, []byte(event.Raw))
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *PostgresPaymentRepository) get(ctx context.Context, query string, args ...any) (*models.Payment, error) {
+	var p models.Payment
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(paymentFields(&p)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func paymentFields(p *models.Payment) []any {
+	return []any{&p.ID, &p.OrderID, &p.Amount, &p.Currency, &p.Status, &p.Provider, &p.ProviderRef, &p.IdempotencyKey,
+		&p.CapturedAmount, &p.RefundedAmount, &p.FailureReason, &p.CreatedAt, &p.UpdatedAt}
+}
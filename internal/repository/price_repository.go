@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrScheduledPriceNotFound = errors.New("scheduled price not found")
+
+type PriceRepository interface {
+	AddHistory(ctx context.Context, change *models.PriceChange) error
+	ListHistory(ctx context.Context, productID int64, limit int) ([]*models.PriceChange, error)
+	// HistorySince возвращает изменения начиная с since и последнее изменение до since
+	HistorySince(ctx context.Context, productIDs []int64, since time.Time) (map[int64][]*models.PriceChange, error)
+
+	CreateSchedule(ctx context.Context, schedule *models.ScheduledPrice) error
+	GetSchedule(ctx context.Context, id int64) (*models.ScheduledPrice, error)
+	ListSchedules(ctx context.Context, productID int64) ([]*models.ScheduledPrice, error)
+	DeleteSchedule(ctx context.Context, id int64) error
+	HasOverlappingSchedule(ctx context.Context, productID int64, startsAt, endsAt time.Time) (bool, error)
+	ActiveSchedules(ctx context.Context, productIDs []int64, at time.Time) (map[int64]*models.ScheduledPrice, error)
+	// DueSchedules возвращает расписания, начало или окончание которых наступило, но ещё не отмечено
+	DueSchedules(ctx context.Context, at time.Time) ([]*models.ScheduledPrice, error)
+	MarkScheduleStarted(ctx context.Context, id int64) error
+	MarkScheduleEnded(ctx context.Context, id int64) error
+}
+
+// PostgresPriceRepository реализует интерфейс PriceRepository
+type PostgresPriceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPriceRepository(db *pgxpool.Pool) PriceRepository {
+	return &PostgresPriceRepository{db: db}
+}
+
+const scheduleColumns = `id, product_id, price, starts_at, ends_at, started, ended, created_at`
+
+func (r *PostgresPriceRepository) AddHistory(ctx context.Context, change *models.PriceChange) error {
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now()
+	}
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO price_history (product_id, old_price, new_price, source, changed_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		change.ProductID, change.OldPrice, change.NewPrice, change.Source, change.ChangedAt).
+		Scan(&change.ID)
+}
+
+func (r *PostgresPriceRepository) ListHistory(ctx context.Context, productID int64, limit int) ([]*models.PriceChange, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, product_id, old_price, new_price, source, changed_at
+		 FROM price_history
+		 WHERE product_id = $1
+		 ORDER BY changed_at DESC, id DESC
+		 LIMIT $2`,
+		productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*models.PriceChange{}
+	for rows.Next() {
+		change, err := scanPriceChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (r *PostgresPriceRepository) HistorySince(ctx context.Context, productIDs []int64, since time.Time) (map[int64][]*models.PriceChange, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, product_id, old_price, new_price, source, changed_at FROM (
+		     SELECT id, product_id, old_price, new_price, source, changed_at
+		     FROM price_history
+		     WHERE product_id = ANY($1) AND changed_at >= $2
+		     UNION ALL
+		     (SELECT DISTINCT ON (product_id) id, product_id, old_price, new_price, source, changed_at
+		      FROM price_history
+		      WHERE product_id = ANY($1) AND changed_at < $2
+		      ORDER BY product_id, changed_at DESC, id DESC)
+		 ) h
+		 ORDER BY product_id, changed_at, id`,
+		productIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[int64][]*models.PriceChange)
+	for rows.Next() {
+		change, err := scanPriceChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		history[change.ProductID] = append(history[change.ProductID], change)
+	}
+	return history, rows.Err()
+}
+
+func (r *PostgresPriceRepository) CreateSchedule(ctx context.Context, schedule *models.ScheduledPrice) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO scheduled_prices (product_id, price, starts_at, ends_at)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		schedule.ProductID, schedule.Price, schedule.StartsAt, schedule.EndsAt).
+		Scan(&schedule.ID, &schedule.CreatedAt)
+}
+
+func (r *PostgresPriceRepository) GetSchedule(ctx context.Context, id int64) (*models.ScheduledPrice, error) {
+	schedule, err := scanSchedule(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+scheduleColumns+` FROM scheduled_prices WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrScheduledPriceNotFound
+	}
+	return schedule, err
+}
+
+func (r *PostgresPriceRepository) ListSchedules(ctx context.Context, productID int64) ([]*models.ScheduledPrice, error) {
+	return r.querySchedules(ctx,
+		`SELECT `+scheduleColumns+` FROM scheduled_prices WHERE product_id = $1 ORDER BY starts_at`,
+		productID)
+}
+
+func (r *PostgresPriceRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM scheduled_prices WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrScheduledPriceNotFound
+	}
+	return nil
+}
+
+func (r *PostgresPriceRepository) HasOverlappingSchedule(ctx context.Context, productID int64, startsAt, endsAt time.Time) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT EXISTS (
+		     SELECT 1 FROM scheduled_prices
+		     WHERE product_id = $1 AND starts_at < $3 AND ends_at > $2
+		 )`,
+		productID, startsAt, endsAt).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresPriceRepository) ActiveSchedules(ctx context.Context, productIDs []int64, at time.Time) (map[int64]*models.ScheduledPrice, error) {
+	schedules, err := r.querySchedules(ctx,
+		`SELECT DISTINCT ON (product_id) `+scheduleColumns+`
+		 FROM scheduled_prices
+		 WHERE product_id = ANY($1) AND starts_at <= $2 AND ends_at > $2
+		 ORDER BY product_id, starts_at DESC`,
+		productIDs, at)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[int64]*models.ScheduledPrice, len(schedules))
+	for _, schedule := range schedules {
+		active[schedule.ProductID] = schedule
+	}
+	return active, nil
+}
+
+func (r *PostgresPriceRepository) DueSchedules(ctx context.Context, at time.Time) ([]*models.ScheduledPrice, error) {
+	return r.querySchedules(ctx,
+		`SELECT `+scheduleColumns+`
+		 FROM scheduled_prices
+		 WHERE (NOT started AND starts_at <= $1) OR (NOT ended AND ends_at <= $1)
+		 ORDER BY starts_at`,
+		at)
+}
+
+func (r *PostgresPriceRepository) MarkScheduleStarted(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.db).Exec(ctx, "UPDATE scheduled_prices SET started = TRUE WHERE id = $1", id)
+	return err
+}
+
+func (r *PostgresPriceRepository) MarkScheduleEnded(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.db).Exec(ctx, "UPDATE scheduled_prices SET ended = TRUE WHERE id = $1", id)
+	return err
+}
+
+func (r *PostgresPriceRepository) querySchedules(ctx context.Context, query string, args ...any) ([]*models.ScheduledPrice, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.ScheduledPrice{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func scanPriceChange(row pgx.Row) (*models.PriceChange, error) {
+	var change models.PriceChange
+	err := row.Scan(&change.ID, &change.ProductID, &change.OldPrice, &change.NewPrice, &change.Source, &change.ChangedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func scanSchedule(row pgx.Row) (*models.ScheduledPrice, error) {
+	var schedule models.ScheduledPrice
+	err := row.Scan(&schedule.ID, &schedule.ProductID, &schedule.Price, &schedule.StartsAt, &schedule.EndsAt, &schedule.Started, &schedule.Ended, &schedule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"shop-api/internal/models"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -13,49 +14,62 @@ var ErrProductNotFound = errors.New("product not found")
 type ProductRepository interface {
 	GetAll(ctx context.Context) ([]*models.Product, error)
 	GetByID(ctx context.Context, id int) (*models.Product, error)
+	// GetByIDs возвращает найденные продукты из списка; отсутствующие ID пропускаются
+	GetByIDs(ctx context.Context, ids []int64) ([]*models.Product, error)
 	Create(ctx context.Context, product *models.Product) error
 	Update(ctx context.Context, product *models.Product) error
 	Delete(ctx context.Context, id int) error
+	// CountLowStock возвращает число продуктов с остатком не больше threshold
+	CountLowStock(ctx context.Context, threshold int) (int, error)
 }
 
-// PostgresProductRepository реализует интерфейс ProductRepository
+// PostgresProductRepository реализует интерфейс ProductRepository.
+// Чтение каталога выполняется на репликах, запись - на основном сервере.
 type PostgresProductRepository struct {
-	db *pgxpool.Pool
+	db       *pgxpool.Pool
+	replicas *Replicas
 }
 
-func NewProductRepository(db *pgxpool.Pool) ProductRepository {
-	return &PostgresProductRepository{db: db}
+func NewProductRepository(db *pgxpool.Pool, replicas *Replicas) ProductRepository {
+	return &PostgresProductRepository{db: db, replicas: replicas}
 }
 
 func (r *PostgresProductRepository) Create(ctx context.Context, product *models.Product) error {
-	err := r.db.QueryRow(ctx,
-		`INSERT INTO products (name, description, price, stock, category) 
-		 VALUES ($1, $2, $3, $4, $5) 
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO products (name, description, price, stock, category, tax_class, weight_kg, length_cm, width_cm, height_cm) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
 		 RETURNING id`,
-		product.Name, product.Description, product.Price, product.Stock, product.Category).
+		product.Name, product.Description, product.Price, product.Stock, product.Category, product.TaxClass,
+		product.WeightKg, product.LengthCm, product.WidthCm, product.HeightCm).
 		Scan(&product.ID)
 	return err
 }
 
 func (r *PostgresProductRepository) GetByID(ctx context.Context, id int) (*models.Product, error) {
 	var product models.Product
-	err := r.db.QueryRow(ctx,
-		`SELECT id, name, description, price, stock, category, created_at, updated_at 
+	err := readConn(ctx, r.db, r.replicas).QueryRow(ctx,
+		`SELECT id, name, description, price, stock, category, tax_class, weight_kg, length_cm, width_cm, height_cm,
+		        rating_avg, rating_count, created_at, updated_at 
 		 FROM products 
 		 WHERE id = $1`,
-		id).Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.Category, &product.CreatedAt, &product.UpdatedAt)
-	if err != nil {
+		id).Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.Category, &product.TaxClass, &product.WeightKg, &product.LengthCm, &product.WidthCm, &product.HeightCm, &product.RatingAvg, &product.RatingCount, &product.CreatedAt, &product.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrProductNotFound
 	}
+	if err != nil {
+		return nil, err
+	}
 	return &product, nil
 }
 
 func (r *PostgresProductRepository) Update(ctx context.Context, product *models.Product) error {
-	result, err := r.db.Exec(ctx,
+	result, err := conn(ctx, r.db).Exec(ctx,
 		`UPDATE products 
-		 SET name = $1, description = $2, price = $3, stock = $4, category = $5, updated_at = NOW()
-		 WHERE id = $6`,
-		product.Name, product.Description, product.Price, product.Stock, product.Category, product.ID)
+		 SET name = $1, description = $2, price = $3, stock = $4, category = $5, tax_class = $6,
+		     weight_kg = $7, length_cm = $8, width_cm = $9, height_cm = $10, updated_at = NOW()
+		 WHERE id = $11`,
+		product.Name, product.Description, product.Price, product.Stock, product.Category, product.TaxClass,
+		product.WeightKg, product.LengthCm, product.WidthCm, product.HeightCm, product.ID)
 	if err != nil {
 		return err
 	}
@@ -67,7 +81,7 @@ func (r *PostgresProductRepository) Update(ctx context.Context, product *models.
 }
 
 func (r *PostgresProductRepository) Delete(ctx context.Context, id int) error {
-	result, err := r.db.Exec(ctx, "DELETE FROM products WHERE id = $1", id)
+	result, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM products WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
@@ -79,8 +93,9 @@ func (r *PostgresProductRepository) Delete(ctx context.Context, id int) error {
 }
 
 func (r *PostgresProductRepository) GetAll(ctx context.Context) ([]*models.Product, error) {
-	rows, err := r.db.Query(ctx,
-		`SELECT id, name, description, price, stock, category, created_at, updated_at 
+	rows, err := readConn(ctx, r.db, r.replicas).Query(ctx,
+		`SELECT id, name, description, price, stock, category, tax_class, weight_kg, length_cm, width_cm, height_cm,
+		        rating_avg, rating_count, created_at, updated_at 
 		 FROM products 
 		 ORDER BY created_at DESC`)
 	if err != nil {
@@ -91,7 +106,7 @@ func (r *PostgresProductRepository) GetAll(ctx context.Context) ([]*models.Produ
 	var products []*models.Product
 	for rows.Next() {
 		var product models.Product
-		err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.Category, &product.CreatedAt, &product.UpdatedAt)
+		err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.Category, &product.TaxClass, &product.WeightKg, &product.LengthCm, &product.WidthCm, &product.HeightCm, &product.RatingAvg, &product.RatingCount, &product.CreatedAt, &product.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -99,3 +114,33 @@ func (r *PostgresProductRepository) GetAll(ctx context.Context) ([]*models.Produ
 	}
 	return products, nil
 }
+
+func (r *PostgresProductRepository) GetByIDs(ctx context.Context, ids []int64) ([]*models.Product, error) {
+	rows, err := readConn(ctx, r.db, r.replicas).Query(ctx,
+		`SELECT id, name, description, price, stock, category, tax_class, weight_kg, length_cm, width_cm, height_cm,
+		        rating_avg, rating_count, created_at, updated_at 
+		 FROM products 
+		 WHERE id = ANY($1)`,
+		ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*models.Product{}
+	for rows.Next() {
+		var product models.Product
+		err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.Category, &product.TaxClass, &product.WeightKg, &product.LengthCm, &product.WidthCm, &product.HeightCm, &product.RatingAvg, &product.RatingCount, &product.CreatedAt, &product.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, &product)
+	}
+	return products, rows.Err()
+}
+
+func (r *PostgresProductRepository) CountLowStock(ctx context.Context, threshold int) (int, error) {
+	var count int
+	err := readConn(ctx, r.db, r.replicas).QueryRow(ctx, `SELECT COUNT(*) FROM products WHERE stock <= $1`, threshold).Scan(&count)
+	return count, err
+}
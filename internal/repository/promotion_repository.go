@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPromotionNotFound   = errors.New("promotion not found")
+	ErrPromotionCodeExists = errors.New("promotion code already exists")
+)
+
+type PromotionRepository interface {
+	GetAll(ctx context.Context) ([]*models.Promotion, error)
+	GetByID(ctx context.Context, id int64) (*models.Promotion, error)
+	GetByCodes(ctx context.Context, codes []string) ([]*models.Promotion, error)
+	// GetAutomatic возвращает активные акции без кода, действующие в момент at
+	GetAutomatic(ctx context.Context, at time.Time) ([]*models.Promotion, error)
+	Create(ctx context.Context, promotion *models.Promotion) error
+	Update(ctx context.Context, promotion *models.Promotion) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// PostgresPromotionRepository реализует интерфейс PromotionRepository
+type PostgresPromotionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPromotionRepository(db *pgxpool.Pool) PromotionRepository {
+	return &PostgresPromotionRepository{db: db}
+}
+
+const promotionColumns = `id, name, COALESCE(code, ''), type, value, buy_quantity, get_quantity, product_ids, categories,
+	min_subtotal, stackable, priority, active, starts_at, ends_at, created_at, updated_at`
+
+func (r *PostgresPromotionRepository) GetAll(ctx context.Context) ([]*models.Promotion, error) {
+	return r.query(ctx, `SELECT `+promotionColumns+` FROM promotions ORDER BY created_at DESC`)
+}
+
+func (r *PostgresPromotionRepository) GetByID(ctx context.Context, id int64) (*models.Promotion, error) {
+	promotion, err := scanPromotion(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+promotionColumns+` FROM promotions WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPromotionNotFound
+	}
+	return promotion, err
+}
+
+func (r *PostgresPromotionRepository) GetByCodes(ctx context.Context, codes []string) ([]*models.Promotion, error) {
+	return r.query(ctx, `SELECT `+promotionColumns+` FROM promotions WHERE code = ANY($1)`, codes)
+}
+
+func (r *PostgresPromotionRepository) GetAutomatic(ctx context.Context, at time.Time) ([]*models.Promotion, error) {
+	return r.query(ctx,
+		`SELECT `+promotionColumns+`
+		 FROM promotions
+		 WHERE code IS NULL AND active
+		   AND (starts_at IS NULL OR starts_at <= $1)
+		   AND (ends_at IS NULL OR ends_at > $1)`,
+		at)
+}
+
+func (r *PostgresPromotionRepository) Create(ctx context.Context, p *models.Promotion) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO promotions (name, code, type, value, buy_quantity, get_quantity, product_ids, categories,
+		     min_subtotal, stackable, priority, active, starts_at, ends_at)
+		 VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		 RETURNING id, created_at, updated_at`,
+		p.Name, p.Code, p.Type, p.Value, p.BuyQuantity, p.GetQuantity, p.ProductIDs, p.Categories,
+		p.MinSubtotal, p.Stackable, p.Priority, p.Active, p.StartsAt, p.EndsAt).
+		Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrPromotionCodeExists
+	}
+	return err
+}
+
+func (r *PostgresPromotionRepository) Update(ctx context.Context, p *models.Promotion) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE promotions
+		 SET name = $1, code = NULLIF($2, ''), type = $3, value = $4, buy_quantity = $5, get_quantity = $6,
+		     product_ids = $7, categories = $8, min_subtotal = $9, stackable = $10, priority = $11, active = $12,
+		     starts_at = $13, ends_at = $14, updated_at = NOW()
+		 WHERE id = $15
+		 RETURNING created_at, updated_at`,
+		p.Name, p.Code, p.Type, p.Value, p.BuyQuantity, p.GetQuantity, p.ProductIDs, p.Categories,
+		p.MinSubtotal, p.Stackable, p.Priority, p.Active, p.StartsAt, p.EndsAt, p.ID).
+		Scan(&p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPromotionNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrPromotionCodeExists
+	}
+	return err
+}
+
+func (r *PostgresPromotionRepository) Delete(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
+
+func (r *PostgresPromotionRepository) query(ctx context.Context, query string, args ...any) ([]*models.Promotion, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []*models.Promotion{}
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	return promotions, rows.Err()
+}
+
+func scanPromotion(row pgx.Row) (*models.Promotion, error) {
+	var p models.Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.Code, &p.Type, &p.Value, &p.BuyQuantity, &p.GetQuantity, &p.ProductIDs, &p.Categories,
+		&p.MinSubtotal, &p.Stackable, &p.Priority, &p.Active, &p.StartsAt, &p.EndsAt,
+		&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrRelationNotFound = errors.New("product relation not found")
+
+type RelatedRepository interface {
+	GetRelations(ctx context.Context, productID int64) ([]*models.ProductRelation, error)
+	// SaveRelation создает связь или обновляет её позицию
+	SaveRelation(ctx context.Context, relation *models.ProductRelation) error
+	DeleteRelation(ctx context.Context, productID, relatedProductID int64, relationType string) error
+	GetSimilar(ctx context.Context, productID int64, limit int) ([]models.ProductSimilarity, error)
+	// ReplaceSimilarities заменяет все рассчитанные похожие товары
+	ReplaceSimilarities(ctx context.Context, similarities []models.ProductSimilarity) error
+}
+
+// PostgresRelatedRepository реализует интерфейс RelatedRepository
+type PostgresRelatedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRelatedRepository(db *pgxpool.Pool) RelatedRepository {
+	return &PostgresRelatedRepository{db: db}
+}
+
+func (r *PostgresRelatedRepository) GetRelations(ctx context.Context, productID int64) ([]*models.ProductRelation, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT product_id, related_product_id, type, position, created_at
+		 FROM product_relations
+		 WHERE product_id = $1
+		 ORDER BY position, created_at`,
+		productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relations := []*models.ProductRelation{}
+	for rows.Next() {
+		var rel models.ProductRelation
+		if err := rows.Scan(&rel.ProductID, &rel.RelatedProductID, &rel.Type, &rel.Position, &rel.CreatedAt); err != nil {
+			return nil, err
+		}
+		relations = append(relations, &rel)
+	}
+	return relations, rows.Err()
+}
+
+func (r *PostgresRelatedRepository) SaveRelation(ctx context.Context, rel *models.ProductRelation) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO product_relations (product_id, related_product_id, type, position)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (product_id, related_product_id, type) DO UPDATE SET position = EXCLUDED.position
+		 RETURNING created_at`,
+		rel.ProductID, rel.RelatedProductID, rel.Type, rel.Position).
+		Scan(&rel.CreatedAt)
+}
+
+func (r *PostgresRelatedRepository) DeleteRelation(ctx context.Context, productID, relatedProductID int64, relationType string) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM product_relations WHERE product_id = $1 AND related_product_id = $2 AND type = $3`,
+		productID, relatedProductID, relationType)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRelationNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRelatedRepository) GetSimilar(ctx context.Context, productID int64, limit int) ([]models.ProductSimilarity, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT product_id, similar_product_id, score
+		 FROM product_similarities
+		 WHERE product_id = $1
+		 ORDER BY score DESC
+		 LIMIT $2`,
+		productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var similarities []models.ProductSimilarity
+	for rows.Next() {
+		var s models.ProductSimilarity
+		if err := rows.Scan(&s.ProductID, &s.SimilarProductID, &s.Score); err != nil {
+			return nil, err
+		}
+		similarities = append(similarities, s)
+	}
+	return similarities, rows.Err()
+}
+
+func (r *PostgresRelatedRepository) ReplaceSimilarities(ctx context.Context, similarities []models.ProductSimilarity) error {
+	productIDs := make([]int64, 0, len(similarities))
+	similarIDs := make([]int64, 0, len(similarities))
+	scores := make([]float64, 0, len(similarities))
+	for _, s := range similarities {
+		productIDs = append(productIDs, s.ProductID)
+		similarIDs = append(similarIDs, s.SimilarProductID)
+		scores = append(scores, s.Score)
+	}
+
+	if _, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM product_similarities`); err != nil {
+		return err
+	}
+	// Продукты, удаленные во время расчета, пропускаются
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO product_similarities (product_id, similar_product_id, score)
+		 SELECT s.product_id, s.similar_product_id, s.score
+		 FROM unnest($1::BIGINT[], $2::BIGINT[], $3::DOUBLE PRECISION[]) AS s(product_id, similar_product_id, score)
+		 WHERE EXISTS (SELECT 1 FROM products WHERE id = s.product_id)
+		   AND EXISTS (SELECT 1 FROM products WHERE id = s.similar_product_id)`,
+		productIDs, similarIDs, scores)
+	return err
+}
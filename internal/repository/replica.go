@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"shop-api/internal/metrics"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNoReplicaAvailable = errors.New("no replica available")
+
+// Подключение реплики к основному серверу и её отставание. Если все полученные изменения
+// применены, отставания нет, даже если на основном сервере давно не было записей, поэтому
+// отдельно проверяется, что WAL receiver получает поток: у отключенной реплики полученное
+// и примененное тоже совпадают. Статус WAL receiver виден роли с pg_read_all_stats.
+const replicaLagQuery = `SELECT
+	NOT pg_is_in_recovery() OR EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming'),
+	CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END::float8`
+
+// Replicas - реплики для чтения. Чтения распределяются по кругу между репликами, которые отвечают
+// и отстают не больше maxLag; состояние обновляет RunHealthCheck. Без доступных реплик
+// чтения выполняются на основном сервере.
+type Replicas struct {
+	replicas []*replica
+	maxLag   time.Duration
+	timeout  time.Duration
+	counter  atomic.Uint64
+	logger   *slog.Logger
+}
+
+type replica struct {
+	name string
+	pool *pgxpool.Pool
+	// До первой проверки реплика не используется
+	available atomic.Bool
+	checked   bool
+}
+
+func NewReplicas(maxLag, timeout time.Duration, logger *slog.Logger) *Replicas {
+	return &Replicas{maxLag: maxLag, timeout: timeout, logger: logger}
+}
+
+// Add добавляет реплику; вызывается до RunHealthCheck
+func (r *Replicas) Add(name string, pool *pgxpool.Pool) {
+	r.replicas = append(r.replicas, &replica{name: name, pool: pool})
+}
+
+// Len возвращает число настроенных реплик
+func (r *Replicas) Len() int {
+	return len(r.replicas)
+}
+
+// Ping возвращает ошибку, если реплики настроены, но ни одна не доступна
+func (r *Replicas) Ping(ctx context.Context) error {
+	if len(r.replicas) > 0 && r.next() == nil {
+		return ErrNoReplicaAvailable
+	}
+	return nil
+}
+
+func (r *Replicas) next() *pgxpool.Pool {
+	n := uint64(len(r.replicas))
+	if n == 0 {
+		return nil
+	}
+	start := r.counter.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.available.Load() {
+			return rep.pool
+		}
+	}
+	return nil
+}
+
+// RunHealthCheck проверяет доступность и отставание реплик раз в interval
+func (r *Replicas) RunHealthCheck(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, rep := range r.replicas {
+			r.check(ctx, rep)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Replicas) check(ctx context.Context, rep *replica) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var streaming bool
+	var lagSeconds float64
+	err := rep.pool.QueryRow(ctx, replicaLagQuery).Scan(&streaming, &lagSeconds)
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	if err == nil {
+		metrics.DBReplicaLag.WithLabelValues(rep.name).Set(lagSeconds)
+		switch {
+		case !streaming:
+			err = errors.New("replica is not streaming from primary")
+		case lag > r.maxLag:
+			err = fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), r.maxLag)
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		// Сервер останавливается; состояние реплики не меняется
+		return
+	}
+
+	available := err == nil
+	if rep.available.Swap(available) != available || !rep.checked {
+		rep.checked = true
+		if available {
+			r.logger.Info("replica available", "replica", rep.name, "lag", lag)
+		} else {
+			r.logger.Warn("replica unavailable, reads fall back to primary", "replica", rep.name, "error", err)
+		}
+	}
+	if available {
+		metrics.DBReplicaAvailable.WithLabelValues(rep.name).Set(1)
+	} else {
+		metrics.DBReplicaAvailable.WithLabelValues(rep.name).Set(0)
+	}
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shop-api/internal/models"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	ErrReviewExists   = errors.New("review for this product already exists")
+)
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review *models.Review) error
+	GetByID(ctx context.Context, id int64) (*models.Review, error)
+	List(ctx context.Context, filter models.ReviewFilter) ([]*models.Review, int, error)
+	SetStatus(ctx context.Context, id int64, status string) (*models.Review, error)
+	SetReply(ctx context.Context, id int64, reply string) (*models.Review, error)
+	Delete(ctx context.Context, id int64) error
+	// AddVote учитывает голос "полезно"; false означает, что клиент уже голосовал
+	AddVote(ctx context.Context, reviewID, customerID int64) (bool, error)
+	// RefreshProductRating пересчитывает средний рейтинг и число одобренных отзывов продукта
+	RefreshProductRating(ctx context.Context, productID int64) error
+}
+
+// PostgresReviewRepository реализует интерфейс ReviewRepository
+type PostgresReviewRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReviewRepository(db *pgxpool.Pool) ReviewRepository {
+	return &PostgresReviewRepository{db: db}
+}
+
+const reviewColumns = `id, product_id, customer_id, author_name, rating, title, body, status, helpful_count,
+	reply, replied_at, moderated_at, created_at, updated_at`
+
+func (r *PostgresReviewRepository) Create(ctx context.Context, review *models.Review) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO product_reviews (product_id, customer_id, author_name, rating, title, body, status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at, updated_at`,
+		review.ProductID, review.CustomerID, review.AuthorName, review.Rating, review.Title, review.Body, review.Status).
+		Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrReviewExists
+	}
+	return err
+}
+
+func (r *PostgresReviewRepository) GetByID(ctx context.Context, id int64) (*models.Review, error) {
+	review, err := scanReview(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+reviewColumns+` FROM product_reviews WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *PostgresReviewRepository) List(ctx context.Context, filter models.ReviewFilter) ([]*models.Review, int, error) {
+	var conditions []string
+	var args []any
+
+	if filter.ProductID != nil {
+		args = append(args, *filter.ProductID)
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM product_reviews`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "created_at DESC, id DESC"
+	if filter.Sort == models.ReviewSortHelpful {
+		order = "helpful_count DESC, " + order
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+reviewColumns+` FROM product_reviews`+where+
+			fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", order, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, total, rows.Err()
+}
+
+func (r *PostgresReviewRepository) SetStatus(ctx context.Context, id int64, status string) (*models.Review, error) {
+	review, err := scanReview(conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE product_reviews
+		 SET status = $1, moderated_at = NOW(), updated_at = NOW()
+		 WHERE id = $2
+		 RETURNING `+reviewColumns,
+		status, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *PostgresReviewRepository) SetReply(ctx context.Context, id int64, reply string) (*models.Review, error) {
+	review, err := scanReview(conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE product_reviews
+		 SET reply = $1, replied_at = CASE WHEN $1 <> '' THEN NOW() END, updated_at = NOW()
+		 WHERE id = $2
+		 RETURNING `+reviewColumns,
+		reply, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *PostgresReviewRepository) Delete(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM product_reviews WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+func (r *PostgresReviewRepository) AddVote(ctx context.Context, reviewID, customerID int64) (bool, error) {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO review_votes (review_id, customer_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		reviewID, customerID)
+	if err != nil || result.RowsAffected() == 0 {
+		return false, err
+	}
+
+	_, err = conn(ctx, r.db).Exec(ctx,
+		`UPDATE product_reviews SET helpful_count = helpful_count + 1 WHERE id = $1`, reviewID)
+	return err == nil, err
+}
+
+func (r *PostgresReviewRepository) RefreshProductRating(ctx context.Context, productID int64) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE products p
+		 SET rating_avg = COALESCE(s.avg, 0), rating_count = s.count
+		 FROM (
+		     SELECT ROUND(AVG(rating), 2) AS avg, COUNT(*) AS count
+		     FROM product_reviews
+		     WHERE product_id = $1 AND status = 'approved'
+		 ) s
+		 WHERE p.id = $1`,
+		productID)
+	return err
+}
+
+func scanReview(row pgx.Row) (*models.Review, error) {
+	var rv models.Review
+	err := row.Scan(&rv.ID, &rv.ProductID, &rv.CustomerID, &rv.AuthorName, &rv.Rating, &rv.Title, &rv.Body, &rv.Status,
+		&rv.HelpfulCount, &rv.Reply, &rv.RepliedAt, &rv.ModeratedAt, &rv.CreatedAt, &rv.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
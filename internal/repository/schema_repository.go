@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SchemaRepository interface {
+	Ping(ctx context.Context) error
+	// AppliedVersion возвращает номер последней примененной миграции; 0 - миграции не учитывались
+	AppliedVersion(ctx context.Context) (int, error)
+}
+
+// PostgresSchemaRepository реализует интерфейс SchemaRepository
+type PostgresSchemaRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSchemaRepository(db *pgxpool.Pool) SchemaRepository {
+	return &PostgresSchemaRepository{db: db}
+}
+
+func (r *PostgresSchemaRepository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx)
+}
+
+// AppliedVersion читает таблицу schema_migrations, которую заполняет цикл применения миграций
+func (r *PostgresSchemaRepository) AppliedVersion(ctx context.Context) (int, error) {
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	err := r.db.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"shop-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrShippingZoneNotFound   = errors.New("shipping zone not found")
+	ErrShippingMethodNotFound = errors.New("shipping method not found")
+)
+
+type ShippingRepository interface {
+	// GetZones возвращает зоны вместе со способами доставки в порядке убывания приоритета
+	GetZones(ctx context.Context) ([]*models.ShippingZone, error)
+	GetZone(ctx context.Context, id int64) (*models.ShippingZone, error)
+	CreateZone(ctx context.Context, zone *models.ShippingZone) error
+	UpdateZone(ctx context.Context, zone *models.ShippingZone) error
+	DeleteZone(ctx context.Context, id int64) error
+}
+
+// PostgresShippingRepository реализует интерфейс ShippingRepository
+type PostgresShippingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewShippingRepository(db *pgxpool.Pool) ShippingRepository {
+	return &PostgresShippingRepository{db: db}
+}
+
+const (
+	shippingZoneColumns   = `id, name, countries, postal_patterns, priority, created_at, updated_at`
+	shippingMethodColumns = `id, zone_id, name, type, cost, threshold, weight_rates, volumetric_divisor, active`
+)
+
+func (r *PostgresShippingRepository) GetZones(ctx context.Context) ([]*models.ShippingZone, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+shippingZoneColumns+` FROM shipping_zones ORDER BY priority DESC, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []*models.ShippingZone{}
+	byID := make(map[int64]*models.ShippingZone)
+	for rows.Next() {
+		zone, err := scanShippingZone(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+		byID[zone.ID] = zone
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	methods, err := r.queryMethods(ctx, `SELECT `+shippingMethodColumns+` FROM shipping_methods ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range methods {
+		if zone, ok := byID[method.ZoneID]; ok {
+			zone.Methods = append(zone.Methods, method)
+		}
+	}
+	return zones, nil
+}
+
+func (r *PostgresShippingRepository) GetZone(ctx context.Context, id int64) (*models.ShippingZone, error) {
+	zone, err := scanShippingZone(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+shippingZoneColumns+` FROM shipping_zones WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShippingZoneNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zone.Methods, err = r.queryMethods(ctx,
+		`SELECT `+shippingMethodColumns+` FROM shipping_methods WHERE zone_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (r *PostgresShippingRepository) CreateZone(ctx context.Context, zone *models.ShippingZone) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO shipping_zones (name, countries, postal_patterns, priority)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at, updated_at`,
+		zone.Name, zone.Countries, zone.PostalPatterns, zone.Priority).
+		Scan(&zone.ID, &zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return r.insertMethods(ctx, zone)
+}
+
+// UpdateZone заменяет параметры зоны и список её способов доставки: способы с ID
+// обновляются на месте, новые добавляются, отсутствующие в списке удаляются
+func (r *PostgresShippingRepository) UpdateZone(ctx context.Context, zone *models.ShippingZone) error {
+	db := conn(ctx, r.db)
+	err := db.QueryRow(ctx,
+		`UPDATE shipping_zones
+		 SET name = $1, countries = $2, postal_patterns = $3, priority = $4, updated_at = NOW()
+		 WHERE id = $5
+		 RETURNING created_at, updated_at`,
+		zone.Name, zone.Countries, zone.PostalPatterns, zone.Priority, zone.ID).
+		Scan(&zone.CreatedAt, &zone.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrShippingZoneNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	keep := []int64{}
+	for _, method := range zone.Methods {
+		if method.ID != 0 {
+			keep = append(keep, method.ID)
+		}
+	}
+	if _, err := db.Exec(ctx,
+		"DELETE FROM shipping_methods WHERE zone_id = $1 AND NOT (id = ANY($2))", zone.ID, keep); err != nil {
+		return err
+	}
+
+	for _, method := range zone.Methods {
+		if method.ID == 0 {
+			continue
+		}
+		weightRates, err := json.Marshal(method.WeightRates)
+		if err != nil {
+			return err
+		}
+
+		method.ZoneID = zone.ID
+		result, err := db.Exec(ctx,
+			`UPDATE shipping_methods
+			 SET name = $1, type = $2, cost = $3, threshold = $4, weight_rates = $5, volumetric_divisor = $6, active = $7
+			 WHERE id = $8 AND zone_id = $9`,
+			method.Name, method.Type, method.Cost, method.Threshold, weightRates, method.VolumetricDivisor, method.Active,
+			method.ID, method.ZoneID)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return ErrShippingMethodNotFound
+		}
+	}
+	return r.insertMethods(ctx, zone)
+}
+
+func (r *PostgresShippingRepository) DeleteZone(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM shipping_zones WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrShippingZoneNotFound
+	}
+	return nil
+}
+
+func (r *PostgresShippingRepository) insertMethods(ctx context.Context, zone *models.ShippingZone) error {
+	for _, method := range zone.Methods {
+		if method.ID != 0 {
+			continue
+		}
+		weightRates, err := json.Marshal(method.WeightRates)
+		if err != nil {
+			return err
+		}
+
+		method.ZoneID = zone.ID
+		err = conn(ctx, r.db).QueryRow(ctx,
+			`INSERT INTO shipping_methods (zone_id, name, type, cost, threshold, weight_rates, volumetric_divisor, active)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			 RETURNING id`,
+			method.ZoneID, method.Name, method.Type, method.Cost, method.Threshold, weightRates, method.VolumetricDivisor, method.Active).
+			Scan(&method.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresShippingRepository) queryMethods(ctx context.Context, query string, args ...any) ([]*models.ShippingMethod, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	methods := []*models.ShippingMethod{}
+	for rows.Next() {
+		var method models.ShippingMethod
+		var weightRates []byte
+		err := rows.Scan(&method.ID, &method.ZoneID, &method.Name, &method.Type, &method.Cost, &method.Threshold, &weightRates, &method.VolumetricDivisor, &method.Active)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(weightRates, &method.WeightRates); err != nil {
+			return nil, err
+		}
+		methods = append(methods, &method)
+	}
+	return methods, rows.Err()
+}
+
+func scanShippingZone(row pgx.Row) (*models.ShippingZone, error) {
+	var zone models.ShippingZone
+	err := row.Scan(&zone.ID, &zone.Name, &zone.Countries, &zone.PostalPatterns, &zone.Priority, &zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	zone.Methods = []*models.ShippingMethod{}
+	return &zone, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	ErrTaxRateExists   = errors.New("tax rate already exists")
+)
+
+type TaxRateRepository interface {
+	GetAll(ctx context.Context) ([]*models.TaxRate, error)
+	GetByID(ctx context.Context, id int64) (*models.TaxRate, error)
+	GetByCountry(ctx context.Context, country string) ([]*models.TaxRate, error)
+	Create(ctx context.Context, rate *models.TaxRate) error
+	Update(ctx context.Context, rate *models.TaxRate) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// PostgresTaxRateRepository реализует интерфейс TaxRateRepository
+type PostgresTaxRateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTaxRateRepository(db *pgxpool.Pool) TaxRateRepository {
+	return &PostgresTaxRateRepository{db: db}
+}
+
+const taxRateColumns = `id, country, region, tax_class, rate, name, created_at, updated_at`
+
+func (r *PostgresTaxRateRepository) GetAll(ctx context.Context) ([]*models.TaxRate, error) {
+	return r.query(ctx, `SELECT `+taxRateColumns+` FROM tax_rates ORDER BY country, region, tax_class`)
+}
+
+func (r *PostgresTaxRateRepository) GetByID(ctx context.Context, id int64) (*models.TaxRate, error) {
+	rate, err := scanTaxRate(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+taxRateColumns+` FROM tax_rates WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTaxRateNotFound
+	}
+	return rate, err
+}
+
+func (r *PostgresTaxRateRepository) GetByCountry(ctx context.Context, country string) ([]*models.TaxRate, error) {
+	return r.query(ctx, `SELECT `+taxRateColumns+` FROM tax_rates WHERE country = $1`, country)
+}
+
+func (r *PostgresTaxRateRepository) Create(ctx context.Context, rate *models.TaxRate) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO tax_rates (country, region, tax_class, rate, name)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at, updated_at`,
+		rate.Country, rate.Region, rate.TaxClass, rate.Rate, rate.Name).
+		Scan(&rate.ID, &rate.CreatedAt, &rate.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrTaxRateExists
+	}
+	return err
+}
+
+func (r *PostgresTaxRateRepository) Update(ctx context.Context, rate *models.TaxRate) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE tax_rates
+		 SET country = $1, region = $2, tax_class = $3, rate = $4, name = $5, updated_at = NOW()
+		 WHERE id = $6
+		 RETURNING created_at, updated_at`,
+		rate.Country, rate.Region, rate.TaxClass, rate.Rate, rate.Name, rate.ID).
+		Scan(&rate.CreatedAt, &rate.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTaxRateNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrTaxRateExists
+	}
+	return err
+}
+
+func (r *PostgresTaxRateRepository) Delete(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM tax_rates WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTaxRateNotFound
+	}
+	return nil
+}
+
+func (r *PostgresTaxRateRepository) query(ctx context.Context, query string, args ...any) ([]*models.TaxRate, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*models.TaxRate{}
+	for rows.Next() {
+		rate, err := scanTaxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func scanTaxRate(row pgx.Row) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	err := row.Scan(&rate.ID, &rate.Country, &rate.Region, &rate.TaxClass, &rate.Rate, &rate.Name, &rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
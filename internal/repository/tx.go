@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBTX - общий интерфейс пула соединений и транзакции
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Transactor выполняет функцию в рамках одной транзакции.
+// Репозитории, вызванные с переданным контекстом, работают внутри неё.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type primaryKey struct{}
+
+// WithPrimary помечает контекст: чтения выполняются на основном сервере, а не на репликах.
+// Нужен, когда запрос должен увидеть только что сделанные изменения.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+type PostgresTransactor struct {
+	db *pgxpool.Pool
+}
+
+func NewTransactor(db *pgxpool.Pool) Transactor {
+	return &PostgresTransactor{db: db}
+}
+
+func (t *PostgresTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Вложенный вызов переиспользует уже открытую транзакцию
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	return pgx.BeginFunc(ctx, t.db, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn возвращает транзакцию из контекста, если она есть, иначе пул
+func conn(ctx context.Context, db *pgxpool.Pool) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// readConn возвращает соединение для чтения: транзакцию из контекста, основной пул, если контекст
+// помечен WithPrimary или доступных реплик нет, иначе следующую доступную реплику
+func readConn(ctx context.Context, db *pgxpool.Pool, replicas *Replicas) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); !primary {
+		if pool := replicas.next(); pool != nil {
+			metrics.DBReads.WithLabelValues("replica").Inc()
+			return pool
+		}
+	}
+	metrics.DBReads.WithLabelValues("primary").Inc()
+	return db
+}
+
+// isUniqueViolation проверяет, что ошибка вызвана нарушением ограничения уникальности
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
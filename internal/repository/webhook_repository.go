@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found")
+)
+
+type WebhookRepository interface {
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id int64) error
+	// GetSubscriptionsForEvent возвращает активные подписки на тип события
+	GetSubscriptionsForEvent(ctx context.Context, eventType string) ([]*models.WebhookSubscription, error)
+	// RecordFailure увеличивает счетчик неудач подряд и возвращает его новое значение
+	RecordFailure(ctx context.Context, subscriptionID int64) (int, error)
+	ResetFailures(ctx context.Context, subscriptionID int64) error
+	Disable(ctx context.Context, subscriptionID int64, reason string) error
+
+	// CreateDelivery не создает повторную доставку того же события подписчику
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ClaimDeliveries захватывает до limit готовых доставок активных подписок на время lease
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// Redeliver ставит доставку в очередь на немедленную отправку с новым циклом повторов
+	Redeliver(ctx context.Context, id int64) error
+	AddAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error
+	GetAttempts(ctx context.Context, deliveryID int64) ([]*models.WebhookDeliveryAttempt, error)
+}
+
+// PostgresWebhookRepository реализует интерфейс WebhookRepository
+type PostgresWebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) WebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+const (
+	webhookSubscriptionColumns = `id, url, event_types, secret, active, consecutive_failures, disabled_reason, created_at, updated_at`
+	webhookDeliveryColumns     = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		last_response_code, last_error, delivered_at, created_at`
+)
+
+func (r *PostgresWebhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return r.querySubscriptions(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+}
+
+func (r *PostgresWebhookRepository) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	sub, err := scanWebhookSubscription(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+	return sub, err
+}
+
+func (r *PostgresWebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO webhook_subscriptions (url, event_types, secret, active)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, consecutive_failures, disabled_reason, created_at, updated_at`,
+		sub.URL, sub.EventTypes, sub.Secret, sub.Active).
+		Scan(&sub.ID, &sub.ConsecutiveFailures, &sub.DisabledReason, &sub.CreatedAt, &sub.UpdatedAt)
+}
+
+func (r *PostgresWebhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE webhook_subscriptions
+		 SET url = $1, event_types = $2, secret = $3, active = $4,
+		     consecutive_failures = $5, disabled_reason = $6, updated_at = NOW()
+		 WHERE id = $7
+		 RETURNING updated_at`,
+		sub.URL, sub.EventTypes, sub.Secret, sub.Active, sub.ConsecutiveFailures, sub.DisabledReason, sub.ID).
+		Scan(&sub.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrWebhookSubscriptionNotFound
+	}
+	return err
+}
+
+func (r *PostgresWebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWebhookRepository) GetSubscriptionsForEvent(ctx context.Context, eventType string) ([]*models.WebhookSubscription, error) {
+	return r.querySubscriptions(ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions
+		 WHERE active AND ($1 = ANY(event_types) OR '*' = ANY(event_types))
+		 ORDER BY id`,
+		eventType)
+}
+
+func (r *PostgresWebhookRepository) RecordFailure(ctx context.Context, subscriptionID int64) (int, error) {
+	var failures int
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1
+		 WHERE id = $1
+		 RETURNING consecutive_failures`,
+		subscriptionID).Scan(&failures)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrWebhookSubscriptionNotFound
+	}
+	return failures, err
+}
+
+func (r *PostgresWebhookRepository) ResetFailures(ctx context.Context, subscriptionID int64) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures <> 0`,
+		subscriptionID)
+	return err
+}
+
+func (r *PostgresWebhookRepository) Disable(ctx context.Context, subscriptionID int64, reason string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_subscriptions SET active = FALSE, disabled_reason = $2, updated_at = NOW() WHERE id = $1`,
+		subscriptionID, reason)
+	return err
+}
+
+func (r *PostgresWebhookRepository) CreateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (subscription_id, event_id) DO NOTHING
+		 RETURNING id, status, next_attempt_at, created_at`,
+		d.SubscriptionID, d.EventID, d.EventType, d.Payload).
+		Scan(&d.ID, &d.Status, &d.NextAttemptAt, &d.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (r *PostgresWebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	deliveries, err := r.queryDeliveries(ctx,
+		`UPDATE webhook_deliveries SET locked_until = NOW() + $2::INTERVAL
+		 WHERE id IN (
+		     SELECT d.id FROM webhook_deliveries d
+		     JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		     WHERE d.status = 'pending'
+		       AND d.next_attempt_at <= NOW()
+		       AND (d.locked_until IS NULL OR d.locked_until < NOW())
+		       AND s.active
+		     ORDER BY d.next_attempt_at
+		     LIMIT $1
+		     FOR UPDATE OF d SKIP LOCKED)
+		 RETURNING `+webhookDeliveryColumns,
+		limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookRepository) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]*models.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		 WHERE subscription_id = $1
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $2 OFFSET $3`,
+		subscriptionID, limit, offset)
+}
+
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $1, attempts = $2, next_attempt_at = $3, last_response_code = $4,
+		     last_error = $5, delivered_at = $6, locked_until = NULL
+		 WHERE id = $7`,
+		d.Status, d.Attempts, d.NextAttemptAt, d.LastResponseCode, d.LastError, d.DeliveredAt, d.ID)
+	return err
+}
+
+func (r *PostgresWebhookRepository) Redeliver(ctx context.Context, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL
+		 WHERE id = $1`,
+		id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWebhookRepository) AddAttempt(ctx context.Context, a *models.WebhookDeliveryAttempt) error {
+	return conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO webhook_delivery_attempts (delivery_id, response_code, error, duration_ms)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		a.DeliveryID, a.ResponseCode, a.Error, a.DurationMs).
+		Scan(&a.ID, &a.CreatedAt)
+}
+
+func (r *PostgresWebhookRepository) GetAttempts(ctx context.Context, deliveryID int64) ([]*models.WebhookDeliveryAttempt, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, delivery_id, response_code, error, duration_ms, created_at
+		 FROM webhook_delivery_attempts
+		 WHERE delivery_id = $1
+		 ORDER BY created_at, id`,
+		deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*models.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var a models.WebhookDeliveryAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.ResponseCode, &a.Error, &a.DurationMs, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &a)
+	}
+	return attempts, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) querySubscriptions(ctx context.Context, sql string, args ...any) ([]*models.WebhookSubscription, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*models.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) queryDeliveries(ctx context.Context, sql string, args ...any) ([]*models.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanWebhookSubscription(row pgx.Row) (*models.WebhookSubscription, error) {
+	var s models.WebhookSubscription
+	err := row.Scan(&s.ID, &s.URL, &s.EventTypes, &s.Secret, &s.Active, &s.ConsecutiveFailures,
+		&s.DisabledReason, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastResponseCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrWishlistNotFound     = errors.New("wishlist not found")
+	ErrWishlistExists       = errors.New("wishlist with this name already exists")
+	ErrWishlistItemNotFound = errors.New("wishlist item not found")
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+type WishlistRepository interface {
+	GetByCustomer(ctx context.Context, customerID int64) ([]*models.Wishlist, error)
+	Get(ctx context.Context, customerID, id int64) (*models.Wishlist, error)
+	GetByShareToken(ctx context.Context, token string) (*models.Wishlist, error)
+	Create(ctx context.Context, wishlist *models.Wishlist) error
+	Rename(ctx context.Context, wishlist *models.Wishlist) error
+	Delete(ctx context.Context, customerID, id int64) error
+	// SetShareToken публикует список по токену; nil закрывает доступ по ссылке
+	SetShareToken(ctx context.Context, customerID, id int64, token *string) error
+
+	GetItems(ctx context.Context, wishlistID int64) ([]*models.WishlistItem, error)
+	// AddItem добавляет продукт; повторное добавление ничего не меняет
+	AddItem(ctx context.Context, wishlistID, productID int64, price float64) error
+	RemoveItem(ctx context.Context, wishlistID, productID int64) error
+
+	// CreateNotifications создает уведомление каждому клиенту, у которого продукт есть в списках желаний
+	CreateNotifications(ctx context.Context, productID int64, notificationType string, oldPrice, newPrice *float64) error
+	GetNotifications(ctx context.Context, customerID int64) ([]*models.WishlistNotification, error)
+	MarkNotificationRead(ctx context.Context, customerID, id int64) error
+}
+
+// PostgresWishlistRepository реализует интерфейс WishlistRepository
+type PostgresWishlistRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWishlistRepository(db *pgxpool.Pool) WishlistRepository {
+	return &PostgresWishlistRepository{db: db}
+}
+
+const wishlistColumns = `id, customer_id, name, share_token, created_at, updated_at`
+
+func (r *PostgresWishlistRepository) GetByCustomer(ctx context.Context, customerID int64) ([]*models.Wishlist, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+wishlistColumns+` FROM wishlists WHERE customer_id = $1 ORDER BY created_at, id`,
+		customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wishlists := []*models.Wishlist{}
+	for rows.Next() {
+		wishlist, err := scanWishlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+	return wishlists, rows.Err()
+}
+
+func (r *PostgresWishlistRepository) Get(ctx context.Context, customerID, id int64) (*models.Wishlist, error) {
+	wishlist, err := scanWishlist(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+wishlistColumns+` FROM wishlists WHERE id = $1 AND customer_id = $2`, id, customerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWishlistNotFound
+	}
+	return wishlist, err
+}
+
+func (r *PostgresWishlistRepository) GetByShareToken(ctx context.Context, token string) (*models.Wishlist, error) {
+	wishlist, err := scanWishlist(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+wishlistColumns+` FROM wishlists WHERE share_token = $1`, token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWishlistNotFound
+	}
+	return wishlist, err
+}
+
+func (r *PostgresWishlistRepository) Create(ctx context.Context, w *models.Wishlist) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO wishlists (customer_id, name) VALUES ($1, $2) RETURNING id, created_at, updated_at`,
+		w.CustomerID, w.Name).
+		Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrWishlistExists
+	}
+	return err
+}
+
+func (r *PostgresWishlistRepository) Rename(ctx context.Context, w *models.Wishlist) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE wishlists SET name = $1, updated_at = NOW()
+		 WHERE id = $2 AND customer_id = $3
+		 RETURNING updated_at`,
+		w.Name, w.ID, w.CustomerID).
+		Scan(&w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrWishlistNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrWishlistExists
+	}
+	return err
+}
+
+func (r *PostgresWishlistRepository) Delete(ctx context.Context, customerID, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		"DELETE FROM wishlists WHERE id = $1 AND customer_id = $2", id, customerID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWishlistRepository) SetShareToken(ctx context.Context, customerID, id int64, token *string) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE wishlists SET share_token = $1, updated_at = NOW() WHERE id = $2 AND customer_id = $3`,
+		token, id, customerID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWishlistRepository) GetItems(ctx context.Context, wishlistID int64) ([]*models.WishlistItem, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT product_id, added_price, created_at FROM wishlist_items
+		 WHERE wishlist_id = $1
+		 ORDER BY created_at DESC`,
+		wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*models.WishlistItem{}
+	for rows.Next() {
+		var item models.WishlistItem
+		if err := rows.Scan(&item.ProductID, &item.AddedPrice, &item.AddedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func (r *PostgresWishlistRepository) AddItem(ctx context.Context, wishlistID, productID int64, price float64) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO wishlist_items (wishlist_id, product_id, added_price)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (wishlist_id, product_id) DO NOTHING`,
+		wishlistID, productID, price)
+	return err
+}
+
+func (r *PostgresWishlistRepository) RemoveItem(ctx context.Context, wishlistID, productID int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		"DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2", wishlistID, productID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWishlistItemNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWishlistRepository) CreateNotifications(ctx context.Context, productID int64, notificationType string, oldPrice, newPrice *float64) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO wishlist_notifications (customer_id, product_id, type, old_price, new_price)
+		 SELECT DISTINCT w.customer_id, $1::BIGINT, $2, $3::DECIMAL, $4::DECIMAL
+		 FROM wishlist_items i
+		 JOIN wishlists w ON w.id = i.wishlist_id
+		 WHERE i.product_id = $1`,
+		productID, notificationType, oldPrice, newPrice)
+	return err
+}
+
+func (r *PostgresWishlistRepository) GetNotifications(ctx context.Context, customerID int64) ([]*models.WishlistNotification, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, customer_id, product_id, type, old_price, new_price, read_at, created_at
+		 FROM wishlist_notifications
+		 WHERE customer_id = $1
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT 100`,
+		customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.WishlistNotification{}
+	for rows.Next() {
+		var n models.WishlistNotification
+		if err := rows.Scan(&n.ID, &n.CustomerID, &n.ProductID, &n.Type, &n.OldPrice, &n.NewPrice, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *PostgresWishlistRepository) MarkNotificationRead(ctx context.Context, customerID, id int64) error {
+	result, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE wishlist_notifications SET read_at = COALESCE(read_at, NOW())
+		 WHERE id = $1 AND customer_id = $2`,
+		id, customerID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func scanWishlist(row pgx.Row) (*models.Wishlist, error) {
+	var w models.Wishlist
+	if err := row.Scan(&w.ID, &w.CustomerID, &w.Name, &w.ShareToken, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
@@ -0,0 +1,63 @@
+package requestctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.5:4000", want: "203.0.113.5"},
+		{name: "spoofed header from untrusted peer", remoteAddr: "203.0.113.5:4000", forwarded: []string{"1.2.3.4"}, realIP: "1.2.3.4", want: "203.0.113.5"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "client-supplied hops ignored", remoteAddr: "10.0.0.2:4000", forwarded: []string{"1.2.3.4, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "chain of trusted proxies", remoteAddr: "192.0.2.10:4000", forwarded: []string{"1.2.3.4, 198.51.100.7", "10.1.1.1"}, want: "198.51.100.7"},
+		{name: "all hops trusted", remoteAddr: "10.0.0.2:4000", forwarded: []string{"10.0.0.3"}, want: "10.0.0.3"},
+		{name: "malformed hop", remoteAddr: "10.0.0.2:4000", forwarded: []string{"unknown"}, want: "10.0.0.2"},
+		{name: "x-real-ip from trusted proxy", remoteAddr: "10.0.0.2:4000", realIP: "198.51.100.8", want: "198.51.100.8"},
+		{name: "ipv6 peer", remoteAddr: "[2001:db8::1]:4000", forwarded: []string{"1.2.3.4"}, want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var got string
+			Middleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r.Context())
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "::1", "fd00::/8"}); err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	for _, value := range []string{"10.0.0.0/33", "proxy.local", ""} {
+		if _, err := ParseTrustedProxies([]string{value}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded, want error", value)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package security
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions - политика CORS. Источник задается полностью (https://shop.example.com),
+// "*" разрешает любой источник, https://*.example.com - любой поддомен example.com.
+type CORSOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type CORS struct {
+	opts      CORSOptions
+	anyOrigin bool
+	origins   map[string]bool
+	// Шаблоны поддоменов: схема и суффикс хоста, например https и .example.com
+	wildcards []wildcardOrigin
+}
+
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+// NewCORS проверяет политику. Любой источник вместе с учетными данными не допускается:
+// браузеры отклоняют такой ответ, а отражение Origin открыло бы доступ любому сайту.
+func NewCORS(opts CORSOptions) (*CORS, error) {
+	c := &CORS{opts: opts, origins: make(map[string]bool)}
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "":
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*.")
+			if host == "" || strings.ContainsAny(host, "*/") {
+				return nil, errors.New("invalid wildcard origin: " + origin)
+			}
+			c.wildcards = append(c.wildcards, wildcardOrigin{scheme: scheme, suffix: "." + host})
+		default:
+			u, err := url.Parse(origin)
+			if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+				return nil, errors.New("invalid origin: " + origin)
+			}
+			c.origins[u.Scheme+"://"+u.Host] = true
+		}
+	}
+	if c.anyOrigin && opts.AllowCredentials {
+		return nil, errors.New("CORS credentials cannot be allowed for any origin")
+	}
+	return c, nil
+}
+
+func (c *CORS) allowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok {
+		return false
+	}
+	for _, w := range c.wildcards {
+		if scheme == w.scheme && strings.HasSuffix(host, w.suffix) && len(host) > len(w.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !c.allowed(origin) {
+			// Без заголовков CORS браузер сам заблокирует ответ
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", strings.Join(c.opts.AllowedMethods, ", "))
+			if len(c.opts.AllowedHeaders) > 0 {
+				header.Set("Access-Control-Allow-Headers", strings.Join(c.opts.AllowedHeaders, ", "))
+			}
+			if c.opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(c.opts.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(c.opts.ExposedHeaders, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
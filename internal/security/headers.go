@@ -0,0 +1,54 @@
+package security
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeadersOptions - заголовки безопасности ответов. Пустое значение отключает заголовок.
+type HeadersOptions struct {
+	// HSTS отправляется, только если MaxAge больше нуля; включать при работе за HTTPS
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	ReferrerPolicy        string
+	// CSP для JSON API и отдельно для Swagger UI, которому нужны встроенные скрипты и стили
+	ContentSecurityPolicy        string
+	SwaggerContentSecurityPolicy string
+	SwaggerPathPrefix            string
+}
+
+func Headers(opts HeadersOptions) func(http.Handler) http.Handler {
+	var hsts string
+	if opts.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(opts.HSTSMaxAge.Seconds()))
+		if opts.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", "DENY")
+			if hsts != "" {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			if opts.ReferrerPolicy != "" {
+				header.Set("Referrer-Policy", opts.ReferrerPolicy)
+			}
+
+			csp := opts.ContentSecurityPolicy
+			if opts.SwaggerPathPrefix != "" && strings.HasPrefix(r.URL.Path, opts.SwaggerPathPrefix) {
+				csp = opts.SwaggerContentSecurityPolicy
+			}
+			if csp != "" {
+				header.Set("Content-Security-Policy", csp)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"shop-api/internal/auth"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"shop-api/internal/requestctx"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// Поля, которые меняются при каждой записи и не несут смысла в диффе
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// newAuditEntry формирует запись журнала с дифом полей между before и after.
+// Для создания before равен nil, для удаления - after.
+func newAuditEntry(ctx context.Context, entityType string, entityID int64, action string, before, after any) (*models.AuditEntry, error) {
+	changes, err := diffFields(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditEntry{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      auth.Actor(ctx),
+		RequestID:  requestctx.RequestID(ctx),
+		IP:         requestctx.ClientIP(ctx),
+		Changes:    changes,
+	}, nil
+}
+
+func diffFields(before, after any) (map[string]models.FieldChange, error) {
+	oldFields, err := toFieldMap(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := toFieldMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.FieldChange)
+	for name, oldValue := range oldFields {
+		if auditIgnoredFields[name] {
+			continue
+		}
+		newValue, ok := newFields[name]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[name] = models.FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+	for name, newValue := range newFields {
+		if auditIgnoredFields[name] {
+			continue
+		}
+		if _, ok := oldFields[name]; !ok {
+			changes[name] = models.FieldChange{New: newValue}
+		}
+	}
+	return changes, nil
+}
+
+func toFieldMap(v any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if v == nil {
+		return fields, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+)
+
+var ErrInvalidCart = errors.New("invalid cart")
+
+// Ограничения корзины: продукты загружаются одним запросом, а количество участвует в расчете скидок
+const (
+	maxCartItems        = 100
+	maxCartItemQuantity = 1000
+)
+
+// buildCart формирует корзину по запросу, подставляя действующие цены и категории продуктов
+func buildCart(ctx context.Context, products *ProductService, items []models.CartItemRequest, shippingCost float64) (*models.Cart, error) {
+	if len(items) == 0 || len(items) > maxCartItems || shippingCost < 0 {
+		return nil, ErrInvalidCart
+	}
+
+	// Повторяющиеся продукты объединяются в одну позицию
+	var ids []int64
+	quantities := make(map[int64]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 || item.Quantity > maxCartItemQuantity {
+			return nil, ErrInvalidCart
+		}
+		if _, ok := quantities[item.ProductID]; !ok {
+			ids = append(ids, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+		if quantities[item.ProductID] > maxCartItemQuantity {
+			return nil, ErrInvalidCart
+		}
+	}
+
+	loaded, err := products.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := &models.Cart{ShippingCost: shippingCost}
+	for _, id := range ids {
+		product, ok := loaded[id]
+		if !ok {
+			return nil, repository.ErrProductNotFound
+		}
+		cart.Items = append(cart.Items, models.CartItem{
+			ProductID: product.ID,
+			Quantity:  quantities[id],
+			UnitPrice: product.EffectivePrice,
+			Category:  product.Category,
+			TaxClass:  product.TaxClass,
+			WeightKg:  product.WeightKg,
+			VolumeCm3: product.LengthCm * product.WidthCm * product.HeightCm,
+		})
+	}
+	return cart, nil
+}
+
+// roundMoney округляет сумму до копеек
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
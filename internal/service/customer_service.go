@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/auth"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"strings"
+)
+
+const (
+	auditEntityCustomer = "customer"
+
+	defaultCustomerLimit = 20
+	maxCustomerLimit     = 100
+)
+
+var (
+	ErrUnauthenticated   = errors.New("unauthenticated")
+	ErrCustomerBlocked   = errors.New("customer is blocked")
+	ErrInvalidAddress    = errors.New("invalid address")
+	ErrInvalidPostalCode = errors.New("invalid postal code for country")
+)
+
+type CustomerService struct {
+	repo  repository.CustomerRepository
+	audit repository.AuditRepository
+	tx    repository.Transactor
+}
+
+func NewCustomerService(repo repository.CustomerRepository, audit repository.AuditRepository, tx repository.Transactor) *CustomerService {
+	return &CustomerService{
+		repo:  repo,
+		audit: audit,
+		tx:    tx,
+	}
+}
+
+// GetProfile возвращает профиль текущего пользователя
+func (s *CustomerService) GetProfile(ctx context.Context) (*models.Customer, error) {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.repo.GetByID(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Blocked {
+		return nil, ErrCustomerBlocked
+	}
+	return customer, nil
+}
+
+// UpdateProfile обновляет профиль текущего пользователя, создавая его при первом обращении
+func (s *CustomerService) UpdateProfile(ctx context.Context, req *models.UpdateProfileRequest) (*models.Customer, error) {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer := &models.Customer{
+		ID:        identity.UserID,
+		Email:     identity.Email,
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
+		Phone:     strings.TrimSpace(req.Phone),
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, identity.UserID)
+		if err != nil && !errors.Is(err, repository.ErrCustomerNotFound) {
+			return err
+		}
+		if before != nil && before.Blocked {
+			return ErrCustomerBlocked
+		}
+		return s.repo.Upsert(ctx, customer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetAddresses(ctx context.Context) ([]*models.Address, error) {
+	customer, err := s.GetProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAddresses(ctx, customer.ID)
+}
+
+func (s *CustomerService) CreateAddress(ctx context.Context, req *models.AddressRequest) (*models.Address, error) {
+	customer, err := s.GetProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := newAddress(req)
+	if err != nil {
+		return nil, err
+	}
+	address.CustomerID = customer.ID
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if address.IsDefault {
+			if err := s.repo.ClearDefaultAddress(ctx, customer.ID, address.Type); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.CreateAddress(ctx, address); err != nil {
+			return err
+		}
+		// Первый адрес типа становится адресом по умолчанию
+		if err := s.repo.PromoteDefaultAddress(ctx, customer.ID, address.Type); err != nil {
+			return err
+		}
+		created, err := s.repo.GetAddress(ctx, customer.ID, address.ID)
+		if err != nil {
+			return err
+		}
+		address = created
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *CustomerService) UpdateAddress(ctx context.Context, id int64, req *models.AddressRequest) (*models.Address, error) {
+	customer, err := s.GetProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := newAddress(req)
+	if err != nil {
+		return nil, err
+	}
+	address.ID = id
+	address.CustomerID = customer.ID
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetAddress(ctx, customer.ID, id)
+		if err != nil {
+			return err
+		}
+		if address.IsDefault {
+			if err := s.repo.ClearDefaultAddress(ctx, customer.ID, address.Type); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.UpdateAddress(ctx, address); err != nil {
+			return err
+		}
+		// Адрес мог перестать быть адресом по умолчанию или сменить тип
+		for _, addressType := range []string{before.Type, address.Type} {
+			if err := s.repo.PromoteDefaultAddress(ctx, customer.ID, addressType); err != nil {
+				return err
+			}
+		}
+		updated, err := s.repo.GetAddress(ctx, customer.ID, id)
+		if err != nil {
+			return err
+		}
+		address = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *CustomerService) DeleteAddress(ctx context.Context, id int64) error {
+	customer, err := s.GetProfile(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		address, err := s.repo.GetAddress(ctx, customer.ID, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteAddress(ctx, customer.ID, id); err != nil {
+			return err
+		}
+		return s.repo.PromoteDefaultAddress(ctx, customer.ID, address.Type)
+	})
+}
+
+func (s *CustomerService) ListCustomers(ctx context.Context, filter models.CustomerFilter) (*models.CustomerPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultCustomerLimit
+	}
+	if filter.Limit > maxCustomerLimit {
+		filter.Limit = maxCustomerLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	customers, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CustomerPage{
+		Items:  customers,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id int64) (*models.Customer, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// SetBlocked блокирует или разблокирует учетную запись клиента
+func (s *CustomerService) SetBlocked(ctx context.Context, id int64, blocked bool) (*models.Customer, error) {
+	var customer *models.Customer
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if customer, err = s.repo.SetBlocked(ctx, id, blocked); err != nil {
+			return err
+		}
+		entry, err := newAuditEntry(ctx, auditEntityCustomer, id, models.AuditActionUpdate, before, customer)
+		if err != nil {
+			return err
+		}
+		return s.audit.Create(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// CheckNotBlocked возвращает ErrCustomerBlocked, если текущий пользователь заблокирован.
+// Анонимные запросы, пользователи без профиля и администраторы проходят
+func (s *CustomerService) CheckNotBlocked(ctx context.Context) error {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok || identity.Role == auth.RoleAdmin {
+		return nil
+	}
+	return checkNotBlocked(ctx, s.repo, identity.UserID)
+}
+
+func checkNotBlocked(ctx context.Context, customers repository.CustomerRepository, customerID int64) error {
+	customer, err := customers.GetByID(ctx, customerID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if customer.Blocked {
+		return ErrCustomerBlocked
+	}
+	return nil
+}
+
+func currentIdentity(ctx context.Context) (*auth.Identity, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return identity, nil
+}
+
+func newAddress(req *models.AddressRequest) (*models.Address, error) {
+	address := &models.Address{
+		Type:       req.Type,
+		IsDefault:  req.IsDefault,
+		FirstName:  strings.TrimSpace(req.FirstName),
+		LastName:   strings.TrimSpace(req.LastName),
+		Line1:      strings.TrimSpace(req.Line1),
+		Line2:      strings.TrimSpace(req.Line2),
+		City:       strings.TrimSpace(req.City),
+		Region:     strings.TrimSpace(req.Region),
+		PostalCode: strings.ToUpper(strings.TrimSpace(req.PostalCode)),
+		Country:    normalizeCountry(req.Country),
+		Phone:      strings.TrimSpace(req.Phone),
+	}
+
+	if address.Type != models.AddressTypeShipping && address.Type != models.AddressTypeBilling {
+		return nil, ErrInvalidAddress
+	}
+	if address.Line1 == "" || address.City == "" || len(address.Country) != 2 {
+		return nil, ErrInvalidAddress
+	}
+	if !validPostalCode(address.Country, address.PostalCode) {
+		return nil, ErrInvalidPostalCode
+	}
+	return address, nil
+}
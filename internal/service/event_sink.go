@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"shop-api/internal/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Подписка на все типы событий шины
+	AllEvents = "*"
+
+	redisStreamMaxLen  = 100000
+	natsPublishTimeout = 5 * time.Second
+)
+
+// EventSink - приемник событий outbox. Одно событие может быть доставлено повторно,
+// поэтому потребители должны обрабатывать его идемпотентно по ID.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, event *models.Event) error
+}
+
+type EventHandler func(ctx context.Context, event *models.Event) error
+
+// EventBus доставляет события подписчикам внутри процесса.
+// Ошибка любого подписчика приводит к повторной доставке события всем подписчикам.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string][]EventHandler)}
+}
+
+// Subscribe регистрирует обработчик для типа события или для всех событий (AllEvents)
+func (b *EventBus) Subscribe(eventType string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *EventBus) Name() string {
+	return "bus"
+}
+
+func (b *EventBus) Publish(ctx context.Context, event *models.Event) error {
+	b.mu.RLock()
+	handlers := make([]EventHandler, 0, len(b.handlers[event.Type])+len(b.handlers[AllEvents]))
+	handlers = append(handlers, b.handlers[event.Type]...)
+	handlers = append(handlers, b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RedisStreamSink добавляет события в Redis Stream
+type RedisStreamSink struct {
+	client *redis.Client
+	stream string
+}
+
+func NewRedisStreamSink(client *redis.Client, stream string, logger *slog.Logger) *RedisStreamSink {
+	logger.Info("initializing redis streams event sink", "stream", stream)
+	return &RedisStreamSink{client: client, stream: stream}
+}
+
+func (s *RedisStreamSink) Name() string {
+	return "redis"
+}
+
+func (s *RedisStreamSink) Publish(ctx context.Context, event *models.Event) error {
+	return s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: redisStreamMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":             event.ID,
+			"type":           event.Type,
+			"aggregate_type": event.AggregateType,
+			"aggregate_id":   event.AggregateID,
+			"payload":        string(event.Payload),
+			"created_at":     event.CreatedAt.Format(time.RFC3339Nano),
+		},
+	}).Err()
+}
+
+// NATSSink публикует события в JetStream в subject <prefix>.<тип события> и ждет
+// подтверждения записи в поток. Поток, покрывающий <prefix>.>, создается отдельно;
+// заголовок Nats-Msg-Id позволяет JetStream отбрасывать повторы.
+type NATSSink struct {
+	conn          *nats.Conn
+	js            jetstream.JetStream
+	subjectPrefix string
+}
+
+func NewNATSSink(url, subjectPrefix string, logger *slog.Logger) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name("shop-api"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	logger.Info("initializing nats event sink", "url", url, "subject_prefix", subjectPrefix)
+	return &NATSSink{conn: conn, js: js, subjectPrefix: subjectPrefix}, nil
+}
+
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(s.subjectPrefix + "." + event.Type)
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatInt(event.ID, 10))
+	msg.Data = data
+
+	ctx, cancel := context.WithTimeout(ctx, natsPublishTimeout)
+	defer cancel()
+	// Без потока для subject или при отказе записи PubAck не придет, и событие будет повторено
+	_, err = s.js.PublishMsg(ctx, msg)
+	return err
+}
+
+func (s *NATSSink) Close() {
+	s.conn.Close()
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"shop-api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func runJetStreamServer(t *testing.T) *server.Server {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("start nats server: %v", err)
+	}
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+func newTestNATSSink(t *testing.T, srv *server.Server) *NATSSink {
+	t.Helper()
+	sink, err := NewNATSSink(srv.ClientURL(), "shop.events", slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewNATSSink() error = %v", err)
+	}
+	t.Cleanup(sink.Close)
+	return sink
+}
+
+func TestNATSSinkPublishesToStream(t *testing.T) {
+	srv := runJetStreamServer(t)
+	sink := newTestNATSSink(t, srv)
+	ctx := context.Background()
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "SHOP_EVENTS", Subjects: []string{"shop.events.>"}})
+	if err != nil {
+		t.Fatalf("create stream: %v", err)
+	}
+
+	event := &models.Event{
+		ID:            42,
+		Type:          models.EventProductUpdated,
+		AggregateType: "product",
+		AggregateID:   7,
+		Payload:       json.RawMessage(`{"id":7}`),
+		CreatedAt:     time.Now(),
+	}
+	// Повторная публикация того же события отбрасывается по Nats-Msg-Id
+	for range 2 {
+		if err := sink.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Fatalf("stream has %d messages, want 1", info.State.Msgs)
+	}
+
+	msg, err := stream.GetLastMsgForSubject(ctx, "shop.events."+models.EventProductUpdated)
+	if err != nil {
+		t.Fatalf("get message: %v", err)
+	}
+	if got := msg.Header.Get(nats.MsgIdHdr); got != "42" {
+		t.Errorf("Nats-Msg-Id = %q, want %q", got, "42")
+	}
+	var published models.Event
+	if err := json.Unmarshal(msg.Data, &published); err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	if published.ID != event.ID || published.AggregateID != event.AggregateID {
+		t.Errorf("published event = %+v, want %+v", published, event)
+	}
+}
+
+func TestNATSSinkFailsWithoutStream(t *testing.T) {
+	srv := runJetStreamServer(t)
+	sink := newTestNATSSink(t, srv)
+
+	err := sink.Publish(context.Background(), &models.Event{ID: 1, Type: models.EventProductCreated, Payload: json.RawMessage(`{}`)})
+	if err == nil {
+		t.Fatal("Publish() without a stream succeeded, want error")
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"time"
+)
+
+const (
+	outboxBatchSize = 100
+	// Время, на которое событие закрепляется за экземпляром ретранслятора
+	outboxLease      = time.Minute
+	outboxRetryBase  = time.Second
+	outboxRetryMax   = 10 * time.Minute
+	outboxRetention  = 7 * 24 * time.Hour
+	outboxCleanupGap = time.Hour
+)
+
+// newEvent формирует доменное событие для записи в outbox в транзакции изменения
+func newEvent(aggregateType string, aggregateID int64, eventType string, payload any) (*models.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Event{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Payload:       data,
+	}, nil
+}
+
+// OutboxRelay публикует события из outbox во все приемники с доставкой at-least-once.
+// Событие считается опубликованным, когда его приняли все приемники; при ошибке
+// оно повторяется с экспоненциальной задержкой, и следующие события того же агрегата ждут.
+type OutboxRelay struct {
+	repo   repository.OutboxRepository
+	logger *slog.Logger
+	sinks  []EventSink
+}
+
+func NewOutboxRelay(repo repository.OutboxRepository, logger *slog.Logger, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{repo: repo, logger: logger, sinks: sinks}
+}
+
+// Run опрашивает outbox с заданным интервалом, пока не будет отменен контекст
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		if err := r.relay(ctx); err != nil && ctx.Err() == nil {
+			r.logger.ErrorContext(ctx, "outbox relay error", "error", err)
+		}
+
+		if time.Since(lastCleanup) >= outboxCleanupGap {
+			lastCleanup = time.Now()
+			deleted, err := r.repo.DeletePublishedBefore(ctx, time.Now().Add(-outboxRetention))
+			if err != nil {
+				r.logger.ErrorContext(ctx, "outbox cleanup error", "error", err)
+			} else if deleted > 0 {
+				r.logger.InfoContext(ctx, "published outbox events deleted", "count", deleted)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay публикует пакеты событий, пока в outbox есть готовые к отправке
+func (r *OutboxRelay) relay(ctx context.Context) error {
+	for ctx.Err() == nil {
+		events, err := r.repo.Claim(ctx, outboxBatchSize, outboxLease)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := r.publish(ctx, event); err != nil {
+				return err
+			}
+		}
+		if len(events) < outboxBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, event *models.Event) error {
+	var errs []error
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	if len(errs) == 0 {
+		return r.repo.MarkPublished(ctx, event.ID)
+	}
+
+	publishErr := errors.Join(errs...)
+	delay := backoff(event.Attempts, outboxRetryBase, outboxRetryMax)
+	r.logger.WarnContext(ctx, "outbox event publish failed", "event_id", event.ID, "event_type", event.Type,
+		"attempt", event.Attempts+1, "retry_in", delay, "error", publishErr)
+	return r.repo.MarkFailed(ctx, event.ID, time.Now().Add(delay), publishErr.Error())
+}
+
+// backoff удваивает задержку base с каждой попыткой, не превышая limit
+func backoff(attempts int, base, limit time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"shop-api/internal/models"
+)
+
+// GatewayPaymentProvider - адаптер HTTP API платежного шлюза.
+// Пути и формат запросов соответствуют типичному REST API шлюза и уточняются
+// при подключении конкретного провайдера.
+type GatewayPaymentProvider struct {
+	baseURL       string
+	apiKey        string
+	webhookSecret string
+	client        *http.Client
+}
+
+func NewGatewayPaymentProvider(baseURL, apiKey, webhookSecret string) *GatewayPaymentProvider {
+	return &GatewayPaymentProvider{
+		baseURL:       baseURL,
+		apiKey:        apiKey,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *GatewayPaymentProvider) Name() string {
+	return "gateway"
+}
+
+func (p *GatewayPaymentProvider) Authorize(ctx context.Context, req ProviderPaymentRequest) (string, error) {
+	var resp struct {
+		ID string `json:"id"`
+	}
+	err := p.call(ctx, "/payments", req.IdempotencyKey, map[string]any{
+		"amount":    toMinorUnits(req.Amount),
+		"currency":  req.Currency,
+		"reference": req.Reference,
+		"capture":   false,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (p *GatewayPaymentProvider) Capture(ctx context.Context, providerRef string, amount float64, idempotencyKey string) error {
+	return p.call(ctx, "/payments/"+url.PathEscape(providerRef)+"/capture", idempotencyKey,
+		map[string]any{"amount": toMinorUnits(amount)}, nil)
+}
+
+func (p *GatewayPaymentProvider) Void(ctx context.Context, providerRef string, idempotencyKey string) error {
+	return p.call(ctx, "/payments/"+url.PathEscape(providerRef)+"/void", idempotencyKey, map[string]any{}, nil)
+}
+
+func (p *GatewayPaymentProvider) Refund(ctx context.Context, providerRef string, amount float64, idempotencyKey string) error {
+	return p.call(ctx, "/payments/"+url.PathEscape(providerRef)+"/refunds", idempotencyKey,
+		map[string]any{"amount": toMinorUnits(amount)}, nil)
+}
+
+func (p *GatewayPaymentProvider) ParseWebhook(header http.Header, body []byte) (*models.PaymentWebhookEvent, error) {
+	return parseSignedWebhook(p.webhookSecret, header, body, time.Now())
+}
+
+func (p *GatewayPaymentProvider) call(ctx context.Context, path, idempotencyKey string, body any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return ErrPaymentDeclined
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("payment gateway: unexpected status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// toMinorUnits переводит сумму в копейки/центы
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
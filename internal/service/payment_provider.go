@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"shop-api/internal/models"
+)
+
+var (
+	ErrPaymentDeclined          = errors.New("payment declined")
+	ErrInvalidWebhookSignature  = errors.New("invalid webhook signature")
+	ErrInvalidWebhookPayload    = errors.New("invalid webhook payload")
+	errProviderInvalidOperation = errors.New("operation is not allowed for payment state")
+)
+
+const (
+	webhookSignatureHeader = "X-Webhook-Signature"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+	webhookTolerance       = 5 * time.Minute
+)
+
+// ProviderPaymentRequest - запрос на авторизацию платежа у провайдера
+type ProviderPaymentRequest struct {
+	Amount         float64
+	Currency       string
+	Reference      string
+	IdempotencyKey string
+}
+
+// PaymentProvider - платежный шлюз. Все операции принимают ключ идемпотентности,
+// повтор с тем же ключом не должен приводить к повторному списанию.
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, req ProviderPaymentRequest) (providerRef string, err error)
+	Capture(ctx context.Context, providerRef string, amount float64, idempotencyKey string) error
+	Void(ctx context.Context, providerRef string, idempotencyKey string) error
+	Refund(ctx context.Context, providerRef string, amount float64, idempotencyKey string) error
+	// ParseWebhook проверяет подпись и разбирает событие
+	ParseWebhook(header http.Header, body []byte) (*models.PaymentWebhookEvent, error)
+}
+
+// FakePaymentProvider - провайдер в памяти для локальной разработки и тестов.
+// Все операции выполняются успешно, если допустимы для текущего состояния платежа.
+type FakePaymentProvider struct {
+	webhookSecret string
+
+	mu       sync.Mutex
+	seq      int
+	results  map[string]string
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	amount   float64
+	captured float64
+	refunded float64
+	voided   bool
+}
+
+func NewFakePaymentProvider(webhookSecret string) *FakePaymentProvider {
+	return &FakePaymentProvider{
+		webhookSecret: webhookSecret,
+		results:       make(map[string]string),
+		payments:      make(map[string]*fakePayment),
+	}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakePaymentProvider) Authorize(ctx context.Context, req ProviderPaymentRequest) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ref, ok := p.results[req.IdempotencyKey]; ok {
+		return ref, nil
+	}
+	if req.Amount <= 0 {
+		return "", ErrPaymentDeclined
+	}
+
+	p.seq++
+	ref := "fake_" + strconv.Itoa(p.seq)
+	p.payments[ref] = &fakePayment{amount: req.Amount}
+	p.results[req.IdempotencyKey] = ref
+	return ref, nil
+}
+
+func (p *FakePaymentProvider) Capture(ctx context.Context, providerRef string, amount float64, idempotencyKey string) error {
+	return p.apply(providerRef, idempotencyKey, func(payment *fakePayment) error {
+		if payment.voided || payment.captured > 0 || amount > payment.amount {
+			return errProviderInvalidOperation
+		}
+		payment.captured = amount
+		return nil
+	})
+}
+
+func (p *FakePaymentProvider) Void(ctx context.Context, providerRef string, idempotencyKey string) error {
+	return p.apply(providerRef, idempotencyKey, func(payment *fakePayment) error {
+		if payment.captured > 0 {
+			return errProviderInvalidOperation
+		}
+		payment.voided = true
+		return nil
+	})
+}
+
+func (p *FakePaymentProvider) Refund(ctx context.Context, providerRef string, amount float64, idempotencyKey string) error {
+	return p.apply(providerRef, idempotencyKey, func(payment *fakePayment) error {
+		if roundMoney(payment.refunded+amount) > payment.captured {
+			return errProviderInvalidOperation
+		}
+		payment.refunded += amount
+		return nil
+	})
+}
+
+func (p *FakePaymentProvider) ParseWebhook(header http.Header, body []byte) (*models.PaymentWebhookEvent, error) {
+	return parseSignedWebhook(p.webhookSecret, header, body, time.Now())
+}
+
+func (p *FakePaymentProvider) apply(providerRef, idempotencyKey string, fn func(payment *fakePayment) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.results[idempotencyKey]; ok {
+		return nil
+	}
+	payment, ok := p.payments[providerRef]
+	if !ok {
+		return fmt.Errorf("fake provider: unknown payment %s", providerRef)
+	}
+	if err := fn(payment); err != nil {
+		return err
+	}
+	p.results[idempotencyKey] = providerRef
+	return nil
+}
+
+// SignWebhook возвращает подпись тела вебхука: HMAC-SHA256 от "timestamp.body" в hex
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSignedWebhook проверяет подпись и свежесть вебхука, защищаясь от подделки и повторной отправки
+func parseSignedWebhook(secret string, header http.Header, body []byte, now time.Time) (*models.PaymentWebhookEvent, error) {
+	if secret == "" {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(webhookTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	signature, err := hex.DecodeString(header.Get(webhookSignatureHeader))
+	if err != nil {
+		return nil, ErrInvalidWebhookSignature
+	}
+	expected, _ := hex.DecodeString(SignWebhook(secret, timestamp, body))
+	if !hmac.Equal(signature, expected) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	var event models.PaymentWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Type == "" || event.Data.Reference == "" {
+		return nil, ErrInvalidWebhookPayload
+	}
+	event.Raw = body
+	return &event, nil
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"shop-api/internal/auth"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+)
+
+const auditEntityPayment = "payment"
+
+var (
+	ErrInvalidPayment         = errors.New("invalid payment")
+	ErrIdempotencyKeyRequired = errors.New("idempotency key is required")
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was used with different parameters")
+	ErrInvalidPaymentState    = errors.New("operation is not allowed in current payment state")
+	ErrInvalidPaymentAmount   = errors.New("invalid payment amount")
+	ErrPaymentProviderFailed  = errors.New("payment provider request failed")
+	currencyPattern           = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+type PaymentService struct {
+	repo      repository.PaymentRepository
+	customers repository.CustomerRepository
+	provider  PaymentProvider
+	currency  string
+	audit     repository.AuditRepository
+	outbox    repository.OutboxRepository
+	tx        repository.Transactor
+}
+
+func NewPaymentService(repo repository.PaymentRepository, customers repository.CustomerRepository, provider PaymentProvider, currency string, audit repository.AuditRepository, outbox repository.OutboxRepository, tx repository.Transactor) *PaymentService {
+	return &PaymentService{
+		repo:      repo,
+		customers: customers,
+		provider:  provider,
+		currency:  strings.ToUpper(currency),
+		audit:     audit,
+		outbox:    outbox,
+		tx:        tx,
+	}
+}
+
+// CreatePayment создает и авторизует платеж. Повтор с тем же ключом возвращает
+// уже созданный платеж, а провайдер получает тот же ключ, поэтому двойного списания нет.
+func (s *PaymentService) CreatePayment(ctx context.Context, idempotencyKey string, req *models.CreatePaymentRequest) (*models.Payment, error) {
+	if idempotencyKey == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+	// Ключи разных пользователей не пересекаются
+	principal, ok := auth.Principal(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	idempotencyKey = principal + ":" + idempotencyKey
+
+	// Проверка дублирует middleware RejectBlocked: платеж не должен пройти ни при каком подключении маршрута
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotBlocked(ctx, s.customers, identity.UserID); err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = s.currency
+	}
+	if req.Amount <= 0 || !currencyPattern.MatchString(currency) {
+		return nil, ErrInvalidPayment
+	}
+
+	payment := &models.Payment{
+		OrderID:        req.OrderID,
+		Amount:         roundMoney(req.Amount),
+		Currency:       currency,
+		Status:         models.PaymentStatusPending,
+		Provider:       s.provider.Name(),
+		IdempotencyKey: idempotencyKey,
+	}
+	created, err := s.repo.CreateOrGet(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	if !created && !samePaymentParams(payment, req.OrderID, roundMoney(req.Amount), currency) {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+
+	// Платеж, оставшийся в pending после сбоя, авторизуется повторно с тем же ключом
+	if payment.Status == models.PaymentStatusPending {
+		if payment, err = s.authorize(ctx, payment); err != nil {
+			return payment, err
+		}
+	}
+
+	if req.Capture && payment.Status == models.PaymentStatusAuthorized {
+		if payment, err = s.CapturePayment(ctx, payment.ID, 0); err != nil {
+			return payment, err
+		}
+	}
+
+	return s.GetPayment(ctx, payment.ID)
+}
+
+func (s *PaymentService) GetPayment(ctx context.Context, id int64) (*models.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.repo.GetAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	payment.Attempts = attempts
+	return payment, nil
+}
+
+// CapturePayment списывает авторизованную сумму; нулевая сумма означает полную сумму платежа
+func (s *PaymentService) CapturePayment(ctx context.Context, id int64, amount float64) (*models.Payment, error) {
+	return s.operate(ctx, id, models.PaymentOperationCapture, amount, "")
+}
+
+func (s *PaymentService) VoidPayment(ctx context.Context, id int64) (*models.Payment, error) {
+	return s.operate(ctx, id, models.PaymentOperationVoid, 0, "")
+}
+
+// RefundPayment возвращает сумму (нулевая - весь остаток). Ключ идемпотентности обязателен,
+// так как частичных возвратов по одному платежу может быть несколько.
+func (s *PaymentService) RefundPayment(ctx context.Context, id int64, amount float64, idempotencyKey string) (*models.Payment, error) {
+	if idempotencyKey == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+	return s.operate(ctx, id, models.PaymentOperationRefund, amount, idempotencyKey)
+}
+
+// HandleWebhook проверяет подпись события и применяет его к платежу.
+// Повторно доставленное событие игнорируется.
+func (s *PaymentService) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	event, err := s.provider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		inserted, err := s.repo.SaveWebhookEvent(ctx, s.provider.Name(), event)
+		if err != nil || !inserted {
+			return err
+		}
+
+		// Если платеж не найден, транзакция откатывается и провайдер доставит событие повторно
+		payment, err := s.repo.LockByProviderRef(ctx, s.provider.Name(), event.Data.Reference)
+		if err != nil {
+			return err
+		}
+
+		before := *payment
+		if !applyWebhookEvent(payment, event) {
+			return nil
+		}
+		if err := s.repo.Update(ctx, payment); err != nil {
+			return err
+		}
+		err = s.repo.AddAttempt(ctx, &models.PaymentAttempt{
+			PaymentID:   payment.ID,
+			Operation:   models.PaymentOperationWebhook,
+			Amount:      event.Data.Amount,
+			Success:     true,
+			ProviderRef: event.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.recordStatusChange(ctx, before.Status, payment); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, payment.ID, &before, payment)
+	})
+}
+
+func (s *PaymentService) authorize(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	ref, providerErr := s.provider.Authorize(ctx, ProviderPaymentRequest{
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		Reference:      strconv.FormatInt(payment.ID, 10),
+		IdempotencyKey: payment.IdempotencyKey,
+	})
+	if providerErr != nil && !errors.Is(providerErr, ErrPaymentDeclined) {
+		s.recordFailedAttempt(ctx, payment, models.PaymentOperationAuthorize, payment.Amount, providerErr)
+		return payment, fmt.Errorf("%w: %v", ErrPaymentProviderFailed, providerErr)
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.repo.Lock(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+		payment = locked
+		oldStatus := payment.Status
+
+		attempt := &models.PaymentAttempt{
+			PaymentID:   payment.ID,
+			Operation:   models.PaymentOperationAuthorize,
+			Amount:      payment.Amount,
+			Success:     providerErr == nil,
+			ProviderRef: ref,
+		}
+		if providerErr != nil {
+			attempt.Error = providerErr.Error()
+		}
+
+		// Вебхук мог прийти раньше ответа провайдера и уже перевести платеж дальше
+		if payment.Status == models.PaymentStatusPending {
+			if providerErr != nil {
+				payment.Status = models.PaymentStatusFailed
+				payment.FailureReason = providerErr.Error()
+			} else {
+				payment.Status = models.PaymentStatusAuthorized
+			}
+		}
+		if payment.ProviderRef == "" {
+			payment.ProviderRef = ref
+		}
+		if err := s.repo.Update(ctx, payment); err != nil {
+			return err
+		}
+		if err := s.repo.AddAttempt(ctx, attempt); err != nil {
+			return err
+		}
+		return s.recordStatusChange(ctx, oldStatus, payment)
+	})
+	if err != nil {
+		return payment, err
+	}
+	return payment, providerErr
+}
+
+// operate выполняет операцию над платежом у провайдера и фиксирует результат.
+// Провайдер вызывается под блокировкой строки платежа; если фиксация результата не удалась,
+// повтор с тем же ключом безопасен, так как провайдер идемпотентен по ключу.
+func (s *PaymentService) operate(ctx context.Context, id int64, operation string, amount float64, key string) (*models.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Захват и отмена возможны только один раз, поэтому ключ выводится из ключа платежа
+	if key == "" {
+		key = payment.IdempotencyKey + ":" + operation
+	} else {
+		key = payment.IdempotencyKey + ":" + operation + ":" + key
+	}
+
+	// Строка платежа блокируется до обращения к провайдеру: параллельная операция ждет
+	// и проверяет допустимую сумму уже с учетом этой
+	var providerErr error
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.repo.Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		// Операция с этим ключом уже выполнена, в том числе параллельным запросом
+		done, err := s.repo.HasSucceededAttempt(ctx, key)
+		if err != nil || done {
+			return err
+		}
+
+		amount, err = operationAmount(locked, operation, roundMoney(amount))
+		if err != nil {
+			return err
+		}
+		if providerErr = s.callProvider(ctx, locked, operation, amount, key); providerErr != nil {
+			return providerErr
+		}
+
+		before := *locked
+		applyOperation(locked, operation, amount)
+		if err := s.repo.Update(ctx, locked); err != nil {
+			return err
+		}
+		err = s.repo.AddAttempt(ctx, &models.PaymentAttempt{
+			PaymentID:      id,
+			Operation:      operation,
+			Amount:         amount,
+			Success:        true,
+			ProviderRef:    locked.ProviderRef,
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.recordStatusChange(ctx, before.Status, locked); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, &before, locked)
+	})
+	if providerErr != nil {
+		s.recordFailedAttempt(ctx, payment, operation, amount, providerErr)
+		if errors.Is(providerErr, ErrPaymentDeclined) {
+			return nil, providerErr
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProviderFailed, providerErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPayment(ctx, id)
+}
+
+func (s *PaymentService) callProvider(ctx context.Context, payment *models.Payment, operation string, amount float64, key string) error {
+	switch operation {
+	case models.PaymentOperationCapture:
+		return s.provider.Capture(ctx, payment.ProviderRef, amount, key)
+	case models.PaymentOperationVoid:
+		return s.provider.Void(ctx, payment.ProviderRef, key)
+	case models.PaymentOperationRefund:
+		return s.provider.Refund(ctx, payment.ProviderRef, amount, key)
+	}
+	return nil
+}
+
+// recordFailedAttempt сохраняет неудачное обращение к провайдеру; ошибка записи не должна скрывать ошибку провайдера
+func (s *PaymentService) recordFailedAttempt(ctx context.Context, payment *models.Payment, operation string, amount float64, providerErr error) {
+	_ = s.repo.AddAttempt(ctx, &models.PaymentAttempt{
+		PaymentID:   payment.ID,
+		Operation:   operation,
+		Amount:      amount,
+		ProviderRef: payment.ProviderRef,
+		Error:       providerErr.Error(),
+	})
+}
+
+func (s *PaymentService) recordAudit(ctx context.Context, id int64, before, after *models.Payment) error {
+	entry, err := newAuditEntry(ctx, auditEntityPayment, id, models.AuditActionUpdate, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+// recordStatusChange пишет в outbox событие смены статуса платежа
+func (s *PaymentService) recordStatusChange(ctx context.Context, oldStatus string, payment *models.Payment) error {
+	if oldStatus == payment.Status {
+		return nil
+	}
+	event, err := newEvent(models.AggregatePayment, payment.ID, models.EventPaymentStatusChanged,
+		models.PaymentStatusChangedEvent{OldStatus: oldStatus, Payment: payment})
+	if err != nil {
+		return err
+	}
+	return s.outbox.Create(ctx, event)
+}
+
+func samePaymentParams(payment *models.Payment, orderID *int64, amount float64, currency string) bool {
+	sameOrder := (payment.OrderID == nil && orderID == nil) ||
+		(payment.OrderID != nil && orderID != nil && *payment.OrderID == *orderID)
+	return sameOrder && payment.Amount == amount && payment.Currency == currency
+}
+
+// operationAmount проверяет допустимость операции и возвращает итоговую сумму
+func operationAmount(payment *models.Payment, operation string, amount float64) (float64, error) {
+	if amount < 0 {
+		return 0, ErrInvalidPaymentAmount
+	}
+
+	switch operation {
+	case models.PaymentOperationCapture:
+		if payment.Status != models.PaymentStatusAuthorized {
+			return 0, ErrInvalidPaymentState
+		}
+		if amount == 0 {
+			return payment.Amount, nil
+		}
+		if amount > payment.Amount {
+			return 0, ErrInvalidPaymentAmount
+		}
+	case models.PaymentOperationVoid:
+		if payment.Status != models.PaymentStatusAuthorized {
+			return 0, ErrInvalidPaymentState
+		}
+	case models.PaymentOperationRefund:
+		if payment.Status != models.PaymentStatusCaptured && payment.Status != models.PaymentStatusPartiallyRefunded {
+			return 0, ErrInvalidPaymentState
+		}
+		remaining := roundMoney(payment.CapturedAmount - payment.RefundedAmount)
+		if amount == 0 {
+			return remaining, nil
+		}
+		if amount > remaining {
+			return 0, ErrInvalidPaymentAmount
+		}
+	}
+	return amount, nil
+}
+
+func applyOperation(payment *models.Payment, operation string, amount float64) {
+	switch operation {
+	case models.PaymentOperationCapture:
+		payment.Status = models.PaymentStatusCaptured
+		payment.CapturedAmount = amount
+	case models.PaymentOperationVoid:
+		payment.Status = models.PaymentStatusVoided
+	case models.PaymentOperationRefund:
+		payment.RefundedAmount = roundMoney(min(payment.RefundedAmount+amount, payment.CapturedAmount))
+		payment.Status = refundStatus(payment)
+	}
+}
+
+// applyWebhookEvent применяет событие провайдера. Суммы в событиях накопительные,
+// поэтому событие об операции, уже выполненной через API, ничего не меняет.
+func applyWebhookEvent(payment *models.Payment, event *models.PaymentWebhookEvent) bool {
+	open := payment.Status == models.PaymentStatusPending || payment.Status == models.PaymentStatusAuthorized
+
+	switch event.Type {
+	case models.PaymentEventAuthorized:
+		if payment.Status != models.PaymentStatusPending {
+			return false
+		}
+		payment.Status = models.PaymentStatusAuthorized
+	case models.PaymentEventCaptured:
+		if !open {
+			return false
+		}
+		payment.Status = models.PaymentStatusCaptured
+		payment.CapturedAmount = payment.Amount
+		if event.Data.Amount > 0 {
+			payment.CapturedAmount = roundMoney(event.Data.Amount)
+		}
+	case models.PaymentEventFailed:
+		if !open {
+			return false
+		}
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = event.Data.Reason
+	case models.PaymentEventVoided:
+		if !open {
+			return false
+		}
+		payment.Status = models.PaymentStatusVoided
+	case models.PaymentEventRefunded:
+		refunded := roundMoney(min(event.Data.Amount, payment.CapturedAmount))
+		if refunded <= payment.RefundedAmount {
+			return false
+		}
+		payment.RefundedAmount = refunded
+		payment.Status = refundStatus(payment)
+	default:
+		return false
+	}
+	return true
+}
+
+func refundStatus(payment *models.Payment) string {
+	if payment.RefundedAmount >= payment.CapturedAmount {
+		return models.PaymentStatusRefunded
+	}
+	return models.PaymentStatusPartiallyRefunded
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"time"
+)
+
+const (
+	auditEntityScheduledPrice = "scheduled_price"
+
+	// Окно расчета минимальной цены (директива ЕС Omnibus)
+	lowestPriceWindow   = 30 * 24 * time.Hour
+	defaultHistoryLimit = 100
+)
+
+var (
+	ErrInvalidScheduledPrice = errors.New("invalid scheduled price")
+	ErrScheduleOverlap       = errors.New("scheduled price overlaps existing schedule")
+)
+
+type PricingService struct {
+	repo     repository.PriceRepository
+	products repository.ProductRepository
+	audit    repository.AuditRepository
+	tx       repository.Transactor
+	logger   *slog.Logger
+}
+
+func NewPricingService(repo repository.PriceRepository, products repository.ProductRepository, audit repository.AuditRepository, tx repository.Transactor, logger *slog.Logger) *PricingService {
+	return &PricingService{
+		repo:     repo,
+		products: products,
+		audit:    audit,
+		tx:       tx,
+		logger:   logger,
+	}
+}
+
+// RecordPriceChange добавляет запись в историю цен. Вызывается внутри транзакции изменения продукта.
+func (s *PricingService) RecordPriceChange(ctx context.Context, productID int64, oldPrice *float64, newPrice float64, source string) error {
+	return s.repo.AddHistory(ctx, &models.PriceChange{
+		ProductID: productID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		Source:    source,
+	})
+}
+
+func (s *PricingService) GetPriceHistory(ctx context.Context, productID int64) ([]*models.PriceChange, error) {
+	if _, err := s.products.GetByID(ctx, int(productID)); err != nil {
+		return nil, err
+	}
+	return s.repo.ListHistory(ctx, productID, defaultHistoryLimit)
+}
+
+func (s *PricingService) ListScheduledPrices(ctx context.Context, productID int64) ([]*models.ScheduledPrice, error) {
+	if _, err := s.products.GetByID(ctx, int(productID)); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSchedules(ctx, productID)
+}
+
+func (s *PricingService) CreateScheduledPrice(ctx context.Context, productID int64, req *models.CreateScheduledPriceRequest) (*models.ScheduledPrice, error) {
+	if req.Price <= 0 || !req.EndsAt.After(req.StartsAt) || !req.EndsAt.After(time.Now()) {
+		return nil, ErrInvalidScheduledPrice
+	}
+
+	schedule := &models.ScheduledPrice{
+		ProductID: productID,
+		Price:     req.Price,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.products.GetByID(ctx, int(productID)); err != nil {
+			return err
+		}
+
+		overlaps, err := s.repo.HasOverlappingSchedule(ctx, productID, req.StartsAt, req.EndsAt)
+		if err != nil {
+			return err
+		}
+		if overlaps {
+			return ErrScheduleOverlap
+		}
+
+		if err := s.repo.CreateSchedule(ctx, schedule); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, schedule.ID, models.AuditActionCreate, nil, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *PricingService) DeleteScheduledPrice(ctx context.Context, productID, id int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		schedule, err := s.repo.GetSchedule(ctx, id)
+		if err != nil {
+			return err
+		}
+		if schedule.ProductID != productID {
+			return repository.ErrScheduledPriceNotFound
+		}
+
+		// Досрочное завершение действующей цены тоже попадает в историю
+		if schedule.Started && !schedule.Ended {
+			if err := s.recordScheduleEnd(ctx, schedule, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.DeleteSchedule(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, schedule, nil)
+	})
+}
+
+// ApplyPricing рассчитывает действующую цену, цену "до скидки" и минимальную цену за 30 дней.
+// Запланированные цены учитываются в момент чтения, поэтому результат не зависит от задержки планировщика.
+func (s *PricingService) ApplyPricing(ctx context.Context, products []*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	ids := make([]int64, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	active, err := s.repo.ActiveSchedules(ctx, ids, now)
+	if err != nil {
+		return err
+	}
+
+	since := now.Add(-lowestPriceWindow)
+	for _, schedule := range active {
+		if start := schedule.StartsAt.Add(-lowestPriceWindow); start.Before(since) {
+			since = start
+		}
+	}
+
+	history, err := s.repo.HistorySince(ctx, ids, since)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		// Для товара со скидкой минимальная цена считается за 30 дней до её начала
+		windowEnd := now
+		schedule, onSale := active[product.ID]
+		if onSale {
+			windowEnd = schedule.StartsAt
+		}
+		product.LowestPrice30d = lowestPrice(history[product.ID], windowEnd.Add(-lowestPriceWindow), windowEnd, product.Price)
+
+		product.EffectivePrice = product.Price
+		product.CompareAtPrice = nil
+		if onSale {
+			regular := product.Price
+			product.CompareAtPrice = &regular
+			product.EffectivePrice = schedule.Price
+		}
+	}
+	return nil
+}
+
+// RunScheduler периодически фиксирует в истории начало и окончание запланированных цен
+func (s *PricingService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.applyDueSchedules(ctx, time.Now()); err != nil {
+			s.logger.ErrorContext(ctx, "price scheduler error", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PricingService) applyDueSchedules(ctx context.Context, now time.Time) error {
+	schedules, err := s.repo.DueSchedules(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if !schedule.Started && !schedule.StartsAt.After(now) {
+				if err := s.recordScheduleStart(ctx, schedule); err != nil {
+					return err
+				}
+			}
+			if !schedule.Ended && !schedule.EndsAt.After(now) {
+				if err := s.recordScheduleEnd(ctx, schedule, schedule.EndsAt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		s.logger.InfoContext(ctx, "scheduled price applied", "schedule_id", schedule.ID, "product_id", schedule.ProductID)
+	}
+	return nil
+}
+
+func (s *PricingService) recordScheduleStart(ctx context.Context, schedule *models.ScheduledPrice) error {
+	product, err := s.products.GetByID(ctx, int(schedule.ProductID))
+	if err != nil {
+		return err
+	}
+
+	regular := product.Price
+	err = s.repo.AddHistory(ctx, &models.PriceChange{
+		ProductID: schedule.ProductID,
+		OldPrice:  &regular,
+		NewPrice:  schedule.Price,
+		Source:    models.PriceSourceSchedule,
+		ChangedAt: schedule.StartsAt,
+	})
+	if err != nil {
+		return err
+	}
+	schedule.Started = true
+	return s.repo.MarkScheduleStarted(ctx, schedule.ID)
+}
+
+func (s *PricingService) recordScheduleEnd(ctx context.Context, schedule *models.ScheduledPrice, at time.Time) error {
+	product, err := s.products.GetByID(ctx, int(schedule.ProductID))
+	if err != nil {
+		return err
+	}
+
+	salePrice := schedule.Price
+	err = s.repo.AddHistory(ctx, &models.PriceChange{
+		ProductID: schedule.ProductID,
+		OldPrice:  &salePrice,
+		NewPrice:  product.Price,
+		Source:    models.PriceSourceSchedule,
+		ChangedAt: at,
+	})
+	if err != nil {
+		return err
+	}
+	schedule.Ended = true
+	return s.repo.MarkScheduleEnded(ctx, schedule.ID)
+}
+
+func (s *PricingService) recordAudit(ctx context.Context, id int64, action string, before, after *models.ScheduledPrice) error {
+	entry, err := newAuditEntry(ctx, auditEntityScheduledPrice, id, action, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+// lowestPrice возвращает минимальную цену, действовавшую в интервале [from, to).
+// changes отсортированы по времени; fallback используется, если истории нет.
+func lowestPrice(changes []*models.PriceChange, from, to time.Time, fallback float64) float64 {
+	var lowest float64
+	found := false
+	consider := func(price float64) {
+		if !found || price < lowest {
+			lowest = price
+			found = true
+		}
+	}
+
+	var atStart *float64
+	for _, change := range changes {
+		if change.ChangedAt.Before(from) {
+			price := change.NewPrice
+			atStart = &price
+			continue
+		}
+		if !change.ChangedAt.Before(to) {
+			break
+		}
+		if atStart == nil && change.OldPrice != nil {
+			price := *change.OldPrice
+			atStart = &price
+		}
+		consider(change.NewPrice)
+	}
+	if atStart != nil {
+		consider(*atStart)
+	}
+
+	if !found {
+		return fallback
+	}
+	return lowest
+}
@@ -2,22 +2,48 @@ package service
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"shop-api/internal/cache"
+	"shop-api/internal/metrics"
 	"shop-api/internal/models"
 	"shop-api/internal/repository"
+	"shop-api/internal/tracing"
+	"sort"
+	"time"
 )
 
+const auditEntityProduct = "product"
+
+var ErrInvalidProductSort = errors.New("invalid product sort")
+
+// ProductChangeListener получает изменения продукта в транзакции обновления
+type ProductChangeListener interface {
+	ProductUpdated(ctx context.Context, before, after *models.Product) error
+}
+
 type ProductService struct {
 	repo      repository.ProductRepository
+	audit     repository.AuditRepository
+	outbox    repository.OutboxRepository
+	tx        repository.Transactor
+	pricing   *PricingService
 	cache     *cache.RedisCache
+	logger    *slog.Logger
+	listeners []ProductChangeListener
 	fromCache bool
 }
 
-func NewProductService(repo repository.ProductRepository, cache *cache.RedisCache) *ProductService {
+func NewProductService(repo repository.ProductRepository, audit repository.AuditRepository, outbox repository.OutboxRepository, tx repository.Transactor, pricing *PricingService, cache *cache.RedisCache, logger *slog.Logger, listeners ...ProductChangeListener) *ProductService {
 	return &ProductService{
 		repo:      repo,
+		audit:     audit,
+		outbox:    outbox,
+		tx:        tx,
+		pricing:   pricing,
 		cache:     cache,
+		logger:    logger,
+		listeners: listeners,
 		fromCache: false,
 	}
 }
@@ -26,34 +52,83 @@ func (s *ProductService) IsFromCache() bool {
 	return s.fromCache
 }
 
-func (s *ProductService) GetAllProducts(ctx context.Context) ([]*models.Product, error) {
+func (s *ProductService) GetAllProducts(ctx context.Context, sortBy string) ([]*models.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetAllProducts")
+	defer span.End()
+
+	products, err := s.getAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return products, sortProducts(products, sortBy)
+}
+
+func (s *ProductService) getAllProducts(ctx context.Context) ([]*models.Product, error) {
 	s.fromCache = false // Сбрасываем флаг в начале метода
 
 	// Пробуем получить из кэша
 	products, err := s.cache.GetProducts(ctx)
 	if err == nil && len(products) > 0 {
-		log.Printf("Cache hit: returning %d products from cache", len(products))
 		s.fromCache = true
+		if err := s.pricing.ApplyPricing(ctx, products); err != nil {
+			return nil, err
+		}
 		return products, nil
 	}
 
 	// Если в кэше нет, получаем из БД
 	products, err = s.repo.GetAll(ctx)
 	if err != nil {
-		log.Printf("Error getting products from database: %v", err)
+		s.logger.ErrorContext(ctx, "error getting products from database", "error", err)
 		return nil, err
 	}
 
-	// Сохраняем в кэш
-	if err := s.cache.SetProducts(ctx, products); err != nil {
-		log.Printf("Error caching products: %v", err)
+	// Сохраняем в кэш без учета запланированных цен, они применяются при чтении
+	if err := s.cache.SetProducts(ctx, products); err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+		s.logger.WarnContext(ctx, "error caching products", "error", err)
 	}
 
+	if err := s.pricing.ApplyPricing(ctx, products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
 func (s *ProductService) GetProductByID(ctx context.Context, id int64) (*models.Product, error) {
-	return s.repo.GetByID(ctx, int(id))
+	ctx, span := tracing.Start(ctx, "ProductService.GetProductByID")
+	defer span.End()
+
+	product, err := s.repo.GetByID(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.pricing.ApplyPricing(ctx, []*models.Product{product}); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetProductsByIDs загружает продукты одним запросом; ключ результата - ID продукта
+func (s *ProductService) GetProductsByIDs(ctx context.Context, ids []int64) (map[int64]*models.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetProductsByIDs")
+	defer span.End()
+
+	result := make(map[int64]*models.Product, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pricing.ApplyPricing(ctx, products); err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		result[product.ID] = product
+	}
+	return result, nil
 }
 
 func (s *ProductService) GetProduct(ctx context.Context, id int64) (*models.Product, error) {
@@ -61,78 +136,213 @@ func (s *ProductService) GetProduct(ctx context.Context, id int64) (*models.Prod
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, req *models.CreateProductRequest) (*models.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.CreateProduct")
+	defer span.End()
+
 	product := &models.Product{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
 		Category:    req.Category,
+		TaxClass:    req.TaxClass,
+		WeightKg:    req.WeightKg,
+		LengthCm:    req.LengthCm,
+		WidthCm:     req.WidthCm,
+		HeightCm:    req.HeightCm,
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = models.TaxClassStandard
 	}
 
-	if err := s.repo.Create(ctx, product); err != nil {
+	// Изменение, запись в журнал аудита и событие в outbox выполняются в одной транзакции
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, product); err != nil {
+			return err
+		}
+		if err := s.pricing.RecordPriceChange(ctx, product.ID, nil, product.Price, models.PriceSourceInitial); err != nil {
+			return err
+		}
+		if err := s.recordEvent(ctx, product.ID, models.EventProductCreated, product); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, product.ID, models.AuditActionCreate, nil, product)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Обновляем кэш новыми данными
-	products, err := s.repo.GetAll(ctx)
+	s.refreshCache(ctx)
+	return product, nil
+}
+
+func (s *ProductService) UpdateProduct(ctx context.Context, id int64, req *models.UpdateProductRequest) error {
+	ctx, span := tracing.Start(ctx, "ProductService.UpdateProduct")
+	defer span.End()
+
+	var product *models.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, int(id))
+		if err != nil {
+			return err
+		}
+		product = applyProductUpdate(before, req)
+		if err := s.repo.Update(ctx, product); err != nil {
+			return err
+		}
+		if before.Price != product.Price {
+			oldPrice := before.Price
+			if err := s.pricing.RecordPriceChange(ctx, id, &oldPrice, product.Price, models.PriceSourceManual); err != nil {
+				return err
+			}
+		}
+		for _, listener := range s.listeners {
+			if err := listener.ProductUpdated(ctx, before, product); err != nil {
+				return err
+			}
+		}
+		if err := s.recordEvent(ctx, id, models.EventProductUpdated, models.ProductUpdatedEvent{Before: before, After: product}); err != nil {
+			return err
+		}
+		if before.Stock != product.Stock {
+			event := models.StockChangedEvent{ProductID: id, OldStock: before.Stock, NewStock: product.Stock}
+			if err := s.recordEvent(ctx, id, models.EventStockChanged, event); err != nil {
+				return err
+			}
+		}
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, product)
+	})
 	if err != nil {
-		log.Printf("Error getting products for cache update: %v", err)
-		return product, nil
+		return err
 	}
 
-	if err := s.cache.SetProducts(ctx, products); err != nil {
-		log.Printf("Error updating cache: %v", err)
+	s.refreshCache(ctx)
+	return nil
+}
+
+func (s *ProductService) DeleteProduct(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "ProductService.DeleteProduct")
+	defer span.End()
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, int(id))
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, int(id)); err != nil {
+			return err
+		}
+		if err := s.recordEvent(ctx, id, models.EventProductDeleted, before); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
 	}
 
-	s.fromCache = false
-	return product, nil
+	s.refreshCache(ctx)
+	return nil
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, id int64, req *models.UpdateProductRequest) error {
-	product := &models.Product{
-		ID:          id,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Stock:       req.Stock,
-		Category:    req.Category,
+// RunLowStockGauge раз в interval пересчитывает метрику продуктов с малым остатком.
+// Порог читается при каждом пересчете, так как меняется при перечитывании конфигурации.
+func (s *ProductService) RunLowStockGauge(ctx context.Context, interval time.Duration, threshold func() int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.repo.CountLowStock(ctx, threshold())
+		if err != nil {
+			s.logger.ErrorContext(ctx, "error counting low stock products", "error", err)
+		} else {
+			metrics.LowStockProducts.Set(float64(count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
+}
 
-	if err := s.repo.Update(ctx, product); err != nil {
-		return err
+// refreshCache обновляет кэш каталога после изменения продуктов
+func (s *ProductService) refreshCache(ctx context.Context) {
+	// Пока Redis недоступен, кэш не обновляется; он очищается при восстановлении
+	if !s.cache.Available() {
+		s.fromCache = false
+		return
 	}
 
-	// Обновляем кэш новыми данными
-	products, err := s.repo.GetAll(ctx)
+	// Реплика может еще не получить изменение; кэш заполняется с основного сервера
+	products, err := s.repo.GetAll(repository.WithPrimary(ctx))
 	if err != nil {
-		log.Printf("Error getting products for cache update: %v", err)
-		return nil
+		s.logger.ErrorContext(ctx, "error getting products for cache update", "error", err)
+		return
 	}
 
 	if err := s.cache.SetProducts(ctx, products); err != nil {
-		log.Printf("Error updating cache: %v", err)
+		s.logger.WarnContext(ctx, "error updating products cache", "error", err)
 	}
 
 	s.fromCache = false
-	return nil
 }
 
-func (s *ProductService) DeleteProduct(ctx context.Context, id int64) error {
-	if err := s.repo.Delete(ctx, int(id)); err != nil {
+func (s *ProductService) recordAudit(ctx context.Context, id int64, action string, before, after *models.Product) error {
+	entry, err := newAuditEntry(ctx, auditEntityProduct, id, action, before, after)
+	if err != nil {
 		return err
 	}
+	return s.audit.Create(ctx, entry)
+}
 
-	// Обновляем кэш новыми данными
-	products, err := s.repo.GetAll(ctx)
+func (s *ProductService) recordEvent(ctx context.Context, id int64, eventType string, payload any) error {
+	event, err := newEvent(models.AggregateProduct, id, eventType, payload)
 	if err != nil {
-		log.Printf("Error getting products for cache update: %v", err)
-		return nil
+		return err
 	}
+	return s.outbox.Create(ctx, event)
+}
 
-	if err := s.cache.SetProducts(ctx, products); err != nil {
-		log.Printf("Error updating cache: %v", err)
+// sortProducts сортирует каталог; по умолчанию сохраняется порядок от новых к старым
+func sortProducts(products []*models.Product, sortBy string) error {
+	switch sortBy {
+	case "", models.ProductSortNewest:
+	case models.ProductSortRating:
+		sort.SliceStable(products, func(i, j int) bool {
+			if products[i].RatingAvg != products[j].RatingAvg {
+				return products[i].RatingAvg > products[j].RatingAvg
+			}
+			return products[i].RatingCount > products[j].RatingCount
+		})
+	default:
+		return ErrInvalidProductSort
 	}
-
-	s.fromCache = false
 	return nil
 }
+
+// applyProductUpdate возвращает копию продукта с примененными переданными полями
+func applyProductUpdate(before *models.Product, req *models.UpdateProductRequest) *models.Product {
+	product := *before
+	setIfPresent(&product.Name, req.Name)
+	setIfPresent(&product.Description, req.Description)
+	setIfPresent(&product.Price, req.Price)
+	setIfPresent(&product.Stock, req.Stock)
+	setIfPresent(&product.Category, req.Category)
+	setIfPresent(&product.ImageURL, req.ImageURL)
+	setIfPresent(&product.WeightKg, req.WeightKg)
+	setIfPresent(&product.LengthCm, req.LengthCm)
+	setIfPresent(&product.WidthCm, req.WidthCm)
+	setIfPresent(&product.HeightCm, req.HeightCm)
+	if req.TaxClass != nil && *req.TaxClass != "" {
+		product.TaxClass = *req.TaxClass
+	}
+	return &product
+}
+
+func setIfPresent[T any](dst *T, value *T) {
+	if value != nil {
+		*dst = *value
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"shop-api/internal/models"
+	"slices"
+	"sort"
+)
+
+// promotionLine - позиция корзины с остатком суммы после уже примененных скидок
+type promotionLine struct {
+	item      models.CartItem
+	remaining float64
+}
+
+type promotionResult struct {
+	discounts        []models.AppliedDiscount
+	itemsDiscount    float64
+	shippingDiscount float64
+}
+
+func (r *promotionResult) total() float64 {
+	return r.itemsDiscount + r.shippingDiscount
+}
+
+// evaluatePromotions выбирает наиболее выгодный вариант: все совместимые акции вместе
+// или одна несовместимая акция. Совместимые применяются по убыванию приоритета,
+// каждая - к остатку суммы после предыдущих.
+func evaluatePromotions(cart *models.Cart, promotions []*models.Promotion) *promotionResult {
+	sorted := make([]*models.Promotion, len(promotions))
+	copy(sorted, promotions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var stackable []*models.Promotion
+	var exclusive []*models.Promotion
+	for _, promotion := range sorted {
+		if promotion.Stackable {
+			stackable = append(stackable, promotion)
+		} else {
+			exclusive = append(exclusive, promotion)
+		}
+	}
+
+	best := applyPromotions(cart, stackable)
+	for _, promotion := range exclusive {
+		if result := applyPromotions(cart, []*models.Promotion{promotion}); result.total() > best.total() {
+			best = result
+		}
+	}
+	return best
+}
+
+func applyPromotions(cart *models.Cart, promotions []*models.Promotion) *promotionResult {
+	lines := make([]*promotionLine, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		lines = append(lines, &promotionLine{
+			item:      item,
+			remaining: roundMoney(item.UnitPrice * float64(item.Quantity)),
+		})
+	}
+	shipping := cart.ShippingCost
+
+	result := &promotionResult{}
+	for _, promotion := range promotions {
+		discount := models.AppliedDiscount{
+			PromotionID: promotion.ID,
+			Name:        promotion.Name,
+			Code:        promotion.Code,
+			Type:        promotion.Type,
+		}
+
+		if promotion.Type == models.PromotionFreeShipping {
+			discount.Amount = roundMoney(shipping)
+			shipping = 0
+			result.shippingDiscount += discount.Amount
+		} else {
+			discount.Lines = applyLineDiscount(promotion, eligibleLines(promotion, lines))
+			for _, line := range discount.Lines {
+				discount.Amount += line.Amount
+			}
+			discount.Amount = roundMoney(discount.Amount)
+			result.itemsDiscount += discount.Amount
+		}
+
+		if discount.Amount > 0 {
+			result.discounts = append(result.discounts, discount)
+		}
+	}
+
+	result.itemsDiscount = roundMoney(result.itemsDiscount)
+	result.shippingDiscount = roundMoney(result.shippingDiscount)
+	return result
+}
+
+func eligibleLines(promotion *models.Promotion, lines []*promotionLine) []*promotionLine {
+	if len(promotion.ProductIDs) == 0 && len(promotion.Categories) == 0 {
+		return lines
+	}
+
+	var eligible []*promotionLine
+	for _, line := range lines {
+		if slices.Contains(promotion.ProductIDs, line.item.ProductID) || slices.Contains(promotion.Categories, line.item.Category) {
+			eligible = append(eligible, line)
+		}
+	}
+	return eligible
+}
+
+// applyLineDiscount уменьшает остаток подходящих позиций и возвращает скидку по каждой из них
+func applyLineDiscount(promotion *models.Promotion, lines []*promotionLine) []models.LineDiscount {
+	amounts := make([]float64, len(lines))
+
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		for i, line := range lines {
+			amounts[i] = roundMoney(line.remaining * promotion.Value / 100)
+		}
+
+	case models.PromotionFixedAmount:
+		var subtotal float64
+		for _, line := range lines {
+			subtotal += line.remaining
+		}
+		if subtotal <= 0 {
+			break
+		}
+		total := roundMoney(min(promotion.Value, subtotal))
+		// Распределяем пропорционально сумме позиций, остаток от округления - на последнюю
+		var distributed float64
+		for i, line := range lines {
+			if i == len(lines)-1 {
+				amounts[i] = roundMoney(total - distributed)
+				break
+			}
+			amounts[i] = roundMoney(total * line.remaining / subtotal)
+			distributed += amounts[i]
+		}
+
+	case models.PromotionBuyXGetY:
+		applyBuyXGetY(promotion, lines, amounts)
+	}
+
+	var discounts []models.LineDiscount
+	for i, line := range lines {
+		amount := min(amounts[i], line.remaining)
+		if amount <= 0 {
+			continue
+		}
+		line.remaining = roundMoney(line.remaining - amount)
+		discounts = append(discounts, models.LineDiscount{ProductID: line.item.ProductID, Amount: amount})
+	}
+	return discounts
+}
+
+// applyBuyXGetY: в каждой группе из X+Y единиц (от дорогих к дешевым) самые дешевые Y
+// получают скидку Value процентов (100%, если не задано). Единицы не перебираются по одной:
+// позиции упорядочиваются по цене, и число бесплатных единиц в каждой считается по её месту в ряду.
+func applyBuyXGetY(promotion *models.Promotion, lines []*promotionLine, amounts []float64) {
+	if promotion.BuyQuantity <= 0 || promotion.GetQuantity <= 0 {
+		return
+	}
+	percent := promotion.Value
+	if percent <= 0 || percent > 100 {
+		percent = 100
+	}
+
+	order := make([]int, 0, len(lines))
+	total := 0
+	for i, line := range lines {
+		if line.item.Quantity > 0 {
+			order = append(order, i)
+			total += line.item.Quantity
+		}
+	}
+	unitPrice := func(i int) float64 {
+		return lines[i].remaining / float64(lines[i].item.Quantity)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return unitPrice(order[a]) > unitPrice(order[b]) })
+
+	group := promotion.BuyQuantity + promotion.GetQuantity
+	// Неполная последняя группа скидку не получает
+	full := total / group * group
+	// discounted возвращает число единиц со скидкой среди первых n единиц ряда
+	discounted := func(n int) int {
+		n = min(n, full)
+		return n/group*promotion.GetQuantity + max(0, n%group-promotion.BuyQuantity)
+	}
+
+	offset := 0
+	for _, i := range order {
+		quantity := lines[i].item.Quantity
+		if free := discounted(offset+quantity) - discounted(offset); free > 0 {
+			amounts[i] = roundMoney(float64(free) * unitPrice(i) * percent / 100)
+		}
+		offset += quantity
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"shop-api/internal/models"
+)
+
+func cartOf(items ...models.CartItem) *models.Cart {
+	return &models.Cart{Items: items}
+}
+
+func item(productID int64, quantity int, unitPrice float64, category string) models.CartItem {
+	return models.CartItem{ProductID: productID, Quantity: quantity, UnitPrice: unitPrice, Category: category}
+}
+
+func TestEvaluatePromotions(t *testing.T) {
+	tests := []struct {
+		name       string
+		cart       *models.Cart
+		promotions []*models.Promotion
+		wantItems  float64
+		wantShip   float64
+	}{
+		{
+			name:       "percentage",
+			cart:       cartOf(item(1, 2, 50, "shoes"), item(2, 1, 100, "hats")),
+			promotions: []*models.Promotion{{ID: 1, Type: models.PromotionPercentage, Value: 10, Stackable: true}},
+			wantItems:  20,
+		},
+		{
+			name:       "percentage scoped to category",
+			cart:       cartOf(item(1, 2, 50, "shoes"), item(2, 1, 100, "hats")),
+			promotions: []*models.Promotion{{ID: 1, Type: models.PromotionPercentage, Value: 10, Categories: []string{"hats"}, Stackable: true}},
+			wantItems:  10,
+		},
+		{
+			name:       "fixed amount capped by subtotal",
+			cart:       cartOf(item(1, 1, 30, "")),
+			promotions: []*models.Promotion{{ID: 1, Type: models.PromotionFixedAmount, Value: 50, Stackable: true}},
+			wantItems:  30,
+		},
+		{
+			name: "stackable applied to remainder",
+			cart: cartOf(item(1, 1, 100, "")),
+			promotions: []*models.Promotion{
+				{ID: 1, Type: models.PromotionPercentage, Value: 10, Stackable: true, Priority: 2},
+				{ID: 2, Type: models.PromotionFixedAmount, Value: 10, Stackable: true, Priority: 1},
+			},
+			wantItems: 20,
+		},
+		{
+			name: "best exclusive wins over stack",
+			cart: cartOf(item(1, 1, 100, "")),
+			promotions: []*models.Promotion{
+				{ID: 1, Type: models.PromotionPercentage, Value: 10, Stackable: true},
+				{ID: 2, Type: models.PromotionPercentage, Value: 25},
+			},
+			wantItems: 25,
+		},
+		{
+			name:       "free shipping",
+			cart:       &models.Cart{Items: []models.CartItem{item(1, 1, 10, "")}, ShippingCost: 4.99},
+			promotions: []*models.Promotion{{ID: 1, Type: models.PromotionFreeShipping, Stackable: true}},
+			wantShip:   4.99,
+		},
+		{
+			name:       "buy 2 get 1 takes cheapest units",
+			cart:       cartOf(item(1, 2, 30, ""), item(2, 1, 10, "")),
+			promotions: []*models.Promotion{{ID: 1, Type: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Stackable: true}},
+			wantItems:  10,
+		},
+		{
+			name:       "buy 1 get 1 half price, incomplete group ignored",
+			cart:       cartOf(item(1, 3, 20, "")),
+			promotions: []*models.Promotion{{ID: 1, Type: models.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1, Value: 50, Stackable: true}},
+			wantItems:  10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluatePromotions(tt.cart, tt.promotions)
+			if result.itemsDiscount != tt.wantItems || result.shippingDiscount != tt.wantShip {
+				t.Errorf("discount = %v items, %v shipping; want %v, %v",
+					result.itemsDiscount, result.shippingDiscount, tt.wantItems, tt.wantShip)
+			}
+		})
+	}
+}
+
+// buyXGetYPerUnit - прямой перебор единиц, с которым сверяется расчет по позициям
+func buyXGetYPerUnit(promotion *models.Promotion, lines []*promotionLine) []float64 {
+	type unit struct {
+		line  int
+		price float64
+	}
+	var units []unit
+	for i, line := range lines {
+		price := line.remaining / float64(line.item.Quantity)
+		for q := 0; q < line.item.Quantity; q++ {
+			units = append(units, unit{line: i, price: price})
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].price > units[j].price })
+
+	amounts := make([]float64, len(lines))
+	group := promotion.BuyQuantity + promotion.GetQuantity
+	for start := 0; start+group <= len(units); start += group {
+		for _, u := range units[start+promotion.BuyQuantity : start+group] {
+			amounts[u.line] += u.price
+		}
+	}
+	for i := range amounts {
+		amounts[i] = roundMoney(amounts[i])
+	}
+	return amounts
+}
+
+func TestApplyBuyXGetYMatchesPerUnit(t *testing.T) {
+	carts := [][]models.CartItem{
+		{item(1, 1, 10, "")},
+		{item(1, 5, 10, "")},
+		{item(1, 2, 30, ""), item(2, 3, 10, ""), item(3, 1, 20, "")},
+		{item(1, 4, 9.99, ""), item(2, 4, 9.99, ""), item(3, 7, 1.5, "")},
+		{item(1, 1, 5, ""), item(2, 10, 50, ""), item(3, 2, 25, ""), item(4, 3, 5, "")},
+	}
+	quantities := [][2]int{{1, 1}, {2, 1}, {3, 2}, {1, 4}, {5, 5}}
+
+	for ci, items := range carts {
+		for _, q := range quantities {
+			promotion := &models.Promotion{Type: models.PromotionBuyXGetY, BuyQuantity: q[0], GetQuantity: q[1]}
+			lines := make([]*promotionLine, len(items))
+			for i, it := range items {
+				lines[i] = &promotionLine{item: it, remaining: roundMoney(it.UnitPrice * float64(it.Quantity))}
+			}
+
+			got := make([]float64, len(lines))
+			applyBuyXGetY(promotion, lines, got)
+			want := buyXGetYPerUnit(promotion, lines)
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 0.005 {
+					t.Errorf("cart %d buy %d get %d: line %d discount = %v, want %v", ci, q[0], q[1], i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestApplyBuyXGetYLargeQuantity(t *testing.T) {
+	promotion := &models.Promotion{Type: models.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1}
+	lines := []*promotionLine{{item: item(1, 1_000_000_000, 1, ""), remaining: 1_000_000_000}}
+
+	amounts := make([]float64, 1)
+	applyBuyXGetY(promotion, lines, amounts)
+	if amounts[0] != 500_000_000 {
+		t.Errorf("discount = %v, want 500000000", amounts[0])
+	}
+}
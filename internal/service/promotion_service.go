@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"strings"
+	"time"
+)
+
+const auditEntityPromotion = "promotion"
+
+var (
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	// Лимиты использования считаются по оформленным заказам; пока заказов в API нет,
+	// акции с лимитами не создаются, чтобы лимит не выглядел соблюдаемым
+	ErrPromotionLimitsUnsupported = errors.New("promotion usage limits are not supported without orders")
+)
+
+type PromotionService struct {
+	repo     repository.PromotionRepository
+	products *ProductService
+	audit    repository.AuditRepository
+	tx       repository.Transactor
+}
+
+func NewPromotionService(repo repository.PromotionRepository, products *ProductService, audit repository.AuditRepository, tx repository.Transactor) *PromotionService {
+	return &PromotionService{
+		repo:     repo,
+		products: products,
+		audit:    audit,
+		tx:       tx,
+	}
+}
+
+func (s *PromotionService) GetAllPromotions(ctx context.Context) ([]*models.Promotion, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *PromotionService) GetPromotion(ctx context.Context, id int64) (*models.Promotion, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *PromotionService) CreatePromotion(ctx context.Context, req *models.PromotionRequest) (*models.Promotion, error) {
+	promotion, err := newPromotion(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, promotion); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, promotion.ID, models.AuditActionCreate, nil, promotion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (s *PromotionService) UpdatePromotion(ctx context.Context, id int64, req *models.PromotionRequest) (*models.Promotion, error) {
+	promotion, err := newPromotion(req)
+	if err != nil {
+		return nil, err
+	}
+	promotion.ID = id
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, promotion); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, promotion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (s *PromotionService) DeletePromotion(ctx context.Context, id int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, before, nil)
+	})
+}
+
+// EvaluateCart рассчитывает скидки для корзины, переданной списком продуктов и количеств
+func (s *PromotionService) EvaluateCart(ctx context.Context, req *models.EvaluateCartRequest) (*models.DiscountBreakdown, error) {
+	cart, err := buildCart(ctx, s.products, req.Items, req.ShippingCost)
+	if err != nil {
+		return nil, err
+	}
+	cart.Codes = req.Codes
+	return s.Evaluate(ctx, cart)
+}
+
+// Evaluate применяет к корзине автоматические акции и переданные промокоды
+// и возвращает детализацию скидок
+func (s *PromotionService) Evaluate(ctx context.Context, cart *models.Cart) (*models.DiscountBreakdown, error) {
+	now := time.Now()
+	subtotal := roundMoney(cart.Subtotal())
+
+	candidates, err := s.repo.GetAutomatic(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := normalizeCodes(cart.Codes)
+	var rejected []models.RejectedCode
+	if len(codes) > 0 {
+		found, err := s.repo.GetByCodes(ctx, codes)
+		if err != nil {
+			return nil, err
+		}
+		byCode := make(map[string]*models.Promotion, len(found))
+		for _, promotion := range found {
+			byCode[promotion.Code] = promotion
+		}
+		for _, code := range codes {
+			promotion, ok := byCode[code]
+			if !ok {
+				rejected = append(rejected, models.RejectedCode{Code: code, Reason: models.PromotionRejectNotFound})
+				continue
+			}
+			candidates = append(candidates, promotion)
+		}
+	}
+
+	var eligible []*models.Promotion
+	for _, promotion := range candidates {
+		if reason := promotionIneligibility(promotion, subtotal, now); reason != "" {
+			if promotion.Code != "" {
+				rejected = append(rejected, models.RejectedCode{Code: promotion.Code, Reason: reason})
+			}
+			continue
+		}
+		eligible = append(eligible, promotion)
+	}
+
+	result := evaluatePromotions(cart, eligible)
+
+	// Промокоды, которые не дали скидки в выбранной комбинации, возвращаются с причиной
+	applied := make(map[int64]bool, len(result.discounts))
+	for _, discount := range result.discounts {
+		applied[discount.PromotionID] = true
+	}
+	for _, promotion := range eligible {
+		if promotion.Code == "" || applied[promotion.ID] {
+			continue
+		}
+		reason := models.PromotionRejectNotApplicable
+		if single := applyPromotions(cart, []*models.Promotion{promotion}); single.total() > 0 {
+			reason = models.PromotionRejectNotCombinable
+		}
+		rejected = append(rejected, models.RejectedCode{Code: promotion.Code, Reason: reason})
+	}
+
+	breakdown := &models.DiscountBreakdown{
+		Subtotal:         subtotal,
+		ShippingCost:     roundMoney(cart.ShippingCost),
+		Discounts:        result.discounts,
+		ItemsDiscount:    result.itemsDiscount,
+		ShippingDiscount: result.shippingDiscount,
+		TotalDiscount:    roundMoney(result.total()),
+		RejectedCodes:    rejected,
+	}
+	if breakdown.Discounts == nil {
+		breakdown.Discounts = []models.AppliedDiscount{}
+	}
+	breakdown.Total = roundMoney(breakdown.Subtotal + breakdown.ShippingCost - breakdown.TotalDiscount)
+	return breakdown, nil
+}
+
+func (s *PromotionService) recordAudit(ctx context.Context, id int64, action string, before, after *models.Promotion) error {
+	entry, err := newAuditEntry(ctx, auditEntityPromotion, id, action, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+func promotionIneligibility(p *models.Promotion, subtotal float64, now time.Time) string {
+	switch {
+	case !p.Active:
+		return models.PromotionRejectInactive
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return models.PromotionRejectNotStarted
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return models.PromotionRejectExpired
+	case subtotal < p.MinSubtotal:
+		return models.PromotionRejectMinSubtotal
+	}
+	return ""
+}
+
+func newPromotion(req *models.PromotionRequest) (*models.Promotion, error) {
+	if strings.TrimSpace(req.Name) == "" || req.Value < 0 || req.MinSubtotal < 0 || req.UsageLimit < 0 || req.PerCustomerLimit < 0 {
+		return nil, ErrInvalidPromotion
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, ErrInvalidPromotion
+	}
+	if req.UsageLimit > 0 || req.PerCustomerLimit > 0 {
+		return nil, ErrPromotionLimitsUnsupported
+	}
+
+	switch req.Type {
+	case models.PromotionPercentage:
+		if req.Value <= 0 || req.Value > 100 {
+			return nil, ErrInvalidPromotion
+		}
+	case models.PromotionFixedAmount:
+		if req.Value <= 0 {
+			return nil, ErrInvalidPromotion
+		}
+	case models.PromotionBuyXGetY:
+		if req.BuyQuantity <= 0 || req.GetQuantity <= 0 || req.Value > 100 {
+			return nil, ErrInvalidPromotion
+		}
+	case models.PromotionFreeShipping:
+	default:
+		return nil, ErrInvalidPromotion
+	}
+
+	promotion := &models.Promotion{
+		Name:        req.Name,
+		Code:        normalizeCode(req.Code),
+		Type:        req.Type,
+		Value:       req.Value,
+		BuyQuantity: req.BuyQuantity,
+		GetQuantity: req.GetQuantity,
+		ProductIDs:  req.ProductIDs,
+		Categories:  req.Categories,
+		MinSubtotal: req.MinSubtotal,
+		Stackable:   req.Stackable,
+		Priority:    req.Priority,
+		Active:      true,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+	}
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+	if promotion.ProductIDs == nil {
+		promotion.ProductIDs = []int64{}
+	}
+	if promotion.Categories == nil {
+		promotion.Categories = []string{}
+	}
+	return promotion, nil
+}
+
+// Промокоды не зависят от регистра и пробелов по краям
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func normalizeCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	var normalized []string
+	for _, code := range codes {
+		code = normalizeCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	return normalized
+}
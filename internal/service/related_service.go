@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	auditEntityProductRelation = "product_relation"
+
+	defaultRelatedLimit = 10
+	maxRelatedLimit     = 50
+	// Сколько похожих товаров сохраняется для каждого продукта
+	similarPerProduct = 20
+	minTokenLength    = 3
+)
+
+var ErrInvalidRelation = errors.New("invalid product relation")
+
+type RelatedService struct {
+	repo        repository.RelatedRepository
+	productRepo repository.ProductRepository
+	products    *ProductService
+	audit       repository.AuditRepository
+	tx          repository.Transactor
+	logger      *slog.Logger
+}
+
+func NewRelatedService(repo repository.RelatedRepository, productRepo repository.ProductRepository, products *ProductService, audit repository.AuditRepository, tx repository.Transactor, logger *slog.Logger) *RelatedService {
+	return &RelatedService{
+		repo:        repo,
+		productRepo: productRepo,
+		products:    products,
+		audit:       audit,
+		tx:          tx,
+		logger:      logger,
+	}
+}
+
+// GetRelated возвращает сначала связанные вручную товары, затем похожие из предрасчитанной таблицы
+func (s *RelatedService) GetRelated(ctx context.Context, productID int64, limit int) ([]*models.RelatedProduct, error) {
+	if limit <= 0 {
+		limit = defaultRelatedLimit
+	}
+	if limit > maxRelatedLimit {
+		limit = maxRelatedLimit
+	}
+
+	if _, err := s.products.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	relations, err := s.repo.GetRelations(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	similar, err := s.repo.GetSimilar(ctx, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*models.RelatedProduct, 0, len(relations)+len(similar))
+	ids := make([]int64, 0, len(relations)+len(similar))
+	for _, rel := range relations {
+		candidates = append(candidates, &models.RelatedProduct{Type: rel.Type})
+		ids = append(ids, rel.RelatedProductID)
+	}
+	for _, sim := range similar {
+		candidates = append(candidates, &models.RelatedProduct{Type: models.RelationSimilar, Score: sim.Score})
+		ids = append(ids, sim.SimilarProductID)
+	}
+
+	// Кандидаты без дублей загружаются одним запросом, удаленные продукты пропускаются
+	var unique []int
+	seen := map[int64]bool{productID: true}
+	for i := range candidates {
+		if !seen[ids[i]] {
+			seen[ids[i]] = true
+			unique = append(unique, i)
+		}
+	}
+	uniqueIDs := make([]int64, len(unique))
+	for j, i := range unique {
+		uniqueIDs[j] = ids[i]
+	}
+	products, err := s.products.GetProductsByIDs(ctx, uniqueIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*models.RelatedProduct{}
+	for _, i := range unique {
+		if len(result) == limit {
+			break
+		}
+		product, ok := products[ids[i]]
+		if !ok {
+			continue
+		}
+		candidates[i].Product = product
+		result = append(result, candidates[i])
+	}
+	return result, nil
+}
+
+func (s *RelatedService) GetRelations(ctx context.Context, productID int64) ([]*models.ProductRelation, error) {
+	return s.repo.GetRelations(ctx, productID)
+}
+
+func (s *RelatedService) SaveRelation(ctx context.Context, productID int64, req *models.ProductRelationRequest) (*models.ProductRelation, error) {
+	switch req.Type {
+	case models.RelationCrossSell, models.RelationUpSell, models.RelationAccessory:
+	default:
+		return nil, ErrInvalidRelation
+	}
+	if req.RelatedProductID == productID {
+		return nil, ErrInvalidRelation
+	}
+
+	relation := &models.ProductRelation{
+		ProductID:        productID,
+		RelatedProductID: req.RelatedProductID,
+		Type:             req.Type,
+		Position:         req.Position,
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.productRepo.GetByID(ctx, int(productID)); err != nil {
+			return err
+		}
+		if _, err := s.productRepo.GetByID(ctx, int(req.RelatedProductID)); err != nil {
+			return err
+		}
+		if err := s.repo.SaveRelation(ctx, relation); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, productID, models.AuditActionCreate, nil, relation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relation, nil
+}
+
+func (s *RelatedService) DeleteRelation(ctx context.Context, productID, relatedProductID int64, relationType string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteRelation(ctx, productID, relatedProductID, relationType); err != nil {
+			return err
+		}
+		before := &models.ProductRelation{ProductID: productID, RelatedProductID: relatedProductID, Type: relationType}
+		return s.recordAudit(ctx, productID, models.AuditActionDelete, before, nil)
+	})
+}
+
+// RunSimilarityJob периодически пересчитывает таблицу похожих товаров
+func (s *RelatedService) RunSimilarityJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.recomputeSimilarities(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "similarity job error", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RelatedService) recomputeSimilarities(ctx context.Context) error {
+	start := time.Now()
+	products, err := s.productRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	similarities := computeSimilarities(products, similarPerProduct)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.repo.ReplaceSimilarities(ctx, similarities)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "similarities recomputed", "pairs", len(similarities), "products", len(products), "duration", time.Since(start))
+	return nil
+}
+
+func (s *RelatedService) recordAudit(ctx context.Context, productID int64, action string, before, after *models.ProductRelation) error {
+	entry, err := newAuditEntry(ctx, auditEntityProductRelation, productID, action, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+// computeSimilarities сравнивает товары одной категории по словам названия и описания и по цене.
+// Для каждого товара остаются perProduct самых похожих.
+func computeSimilarities(products []*models.Product, perProduct int) []models.ProductSimilarity {
+	byCategory := make(map[string][]*models.Product)
+	tokens := make(map[int64]map[string]struct{}, len(products))
+	for _, p := range products {
+		category := strings.ToLower(strings.TrimSpace(p.Category))
+		if category == "" {
+			continue
+		}
+		byCategory[category] = append(byCategory[category], p)
+		tokens[p.ID] = tokenize(p.Name + " " + p.Description)
+	}
+
+	var result []models.ProductSimilarity
+	for _, group := range byCategory {
+		for _, a := range group {
+			var candidates []models.ProductSimilarity
+			for _, b := range group {
+				if a.ID == b.ID {
+					continue
+				}
+				score := 0.5 + 0.4*jaccard(tokens[a.ID], tokens[b.ID]) + 0.1*priceProximity(a.Price, b.Price)
+				candidates = append(candidates, models.ProductSimilarity{
+					ProductID:        a.ID,
+					SimilarProductID: b.ID,
+					Score:            math.Round(score*10000) / 10000,
+				})
+			}
+
+			sort.Slice(candidates, func(i, j int) bool {
+				if candidates[i].Score != candidates[j].Score {
+					return candidates[i].Score > candidates[j].Score
+				}
+				return candidates[i].SimilarProductID < candidates[j].SimilarProductID
+			})
+			if len(candidates) > perProduct {
+				candidates = candidates[:perProduct]
+			}
+			result = append(result, candidates...)
+		}
+	}
+	return result
+}
+
+func tokenize(text string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(word) >= minTokenLength {
+			set[word] = struct{}{}
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for token := range a {
+		if _, ok := b[token]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// priceProximity равна 1 для одинаковых цен и убывает к 0 с ростом разницы
+func priceProximity(a, b float64) float64 {
+	high := math.Max(a, b)
+	if high <= 0 {
+		return 1
+	}
+	return 1 - math.Abs(a-b)/high
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	auditEntityReview = "review"
+
+	defaultReviewLimit = 20
+	maxReviewLimit     = 100
+
+	maxReviewTitleLength = 255
+	maxReviewBodyLength  = 5000
+)
+
+var (
+	ErrInvalidReview          = errors.New("invalid review")
+	ErrCustomerProfileMissing = errors.New("customer profile is required")
+	ErrOwnReviewVote          = errors.New("cannot vote for own review")
+)
+
+type ReviewService struct {
+	repo      repository.ReviewRepository
+	customers repository.CustomerRepository
+	products  *ProductService
+	audit     repository.AuditRepository
+	tx        repository.Transactor
+}
+
+func NewReviewService(repo repository.ReviewRepository, customers repository.CustomerRepository, products *ProductService, audit repository.AuditRepository, tx repository.Transactor) *ReviewService {
+	return &ReviewService{
+		repo:      repo,
+		customers: customers,
+		products:  products,
+		audit:     audit,
+		tx:        tx,
+	}
+}
+
+// CreateReview добавляет отзыв текущего клиента; отзыв публикуется после модерации
+func (s *ReviewService) CreateReview(ctx context.Context, productID int64, req *models.CreateReviewRequest) (*models.Review, error) {
+	customer, err := s.currentCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.Review{
+		ProductID:  productID,
+		CustomerID: customer.ID,
+		AuthorName: strings.TrimSpace(customer.FirstName),
+		Rating:     req.Rating,
+		Title:      strings.TrimSpace(req.Title),
+		Body:       strings.TrimSpace(req.Body),
+		Status:     models.ReviewStatusPending,
+	}
+	if review.Rating < 1 || review.Rating > 5 ||
+		utf8.RuneCountInString(review.Title) > maxReviewTitleLength ||
+		utf8.RuneCountInString(review.Body) > maxReviewBodyLength {
+		return nil, ErrInvalidReview
+	}
+
+	if _, err := s.products.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// GetProductReviews возвращает опубликованные отзывы о продукте
+func (s *ReviewService) GetProductReviews(ctx context.Context, productID int64, filter models.ReviewFilter) (*models.ReviewPage, error) {
+	filter.ProductID = &productID
+	filter.Status = models.ReviewStatusApproved
+	return s.list(ctx, filter)
+}
+
+// GetReviews возвращает отзывы для модерации; по умолчанию - ожидающие проверки
+func (s *ReviewService) GetReviews(ctx context.Context, filter models.ReviewFilter) (*models.ReviewPage, error) {
+	if filter.Status == "" {
+		filter.Status = models.ReviewStatusPending
+	}
+	switch filter.Status {
+	case models.ReviewStatusPending, models.ReviewStatusApproved, models.ReviewStatusRejected:
+	default:
+		return nil, ErrInvalidReview
+	}
+	return s.list(ctx, filter)
+}
+
+// VoteHelpful отмечает опубликованный отзыв как полезный; повторный голос клиента не учитывается
+func (s *ReviewService) VoteHelpful(ctx context.Context, id int64) (*models.Review, error) {
+	customer, err := s.currentCustomer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != models.ReviewStatusApproved {
+		return nil, repository.ErrReviewNotFound
+	}
+	if review.CustomerID == customer.ID {
+		return nil, ErrOwnReviewVote
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.repo.AddVote(ctx, id, customer.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *ReviewService) ApproveReview(ctx context.Context, id int64) (*models.Review, error) {
+	return s.setStatus(ctx, id, models.ReviewStatusApproved)
+}
+
+func (s *ReviewService) RejectReview(ctx context.Context, id int64) (*models.Review, error) {
+	return s.setStatus(ctx, id, models.ReviewStatusRejected)
+}
+
+// ReplyToReview сохраняет ответ магазина на отзыв
+func (s *ReviewService) ReplyToReview(ctx context.Context, id int64, req *models.ReviewReplyRequest) (*models.Review, error) {
+	reply := strings.TrimSpace(req.Reply)
+	if utf8.RuneCountInString(reply) > maxReviewBodyLength {
+		return nil, ErrInvalidReview
+	}
+
+	var review *models.Review
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if review, err = s.repo.SetReply(ctx, id, reply); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *ReviewService) DeleteReview(ctx context.Context, id int64) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := s.repo.RefreshProductRating(ctx, before.ProductID); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.products.refreshCache(ctx)
+	return nil
+}
+
+// setStatus меняет статус модерации и в той же транзакции пересчитывает рейтинг продукта
+func (s *ReviewService) setStatus(ctx context.Context, id int64, status string) (*models.Review, error) {
+	var review *models.Review
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if review, err = s.repo.SetStatus(ctx, id, status); err != nil {
+			return err
+		}
+		if err := s.repo.RefreshProductRating(ctx, review.ProductID); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.products.refreshCache(ctx)
+	return review, nil
+}
+
+func (s *ReviewService) list(ctx context.Context, filter models.ReviewFilter) (*models.ReviewPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultReviewLimit
+	}
+	if filter.Limit > maxReviewLimit {
+		filter.Limit = maxReviewLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	switch filter.Sort {
+	case "", models.ReviewSortNewest, models.ReviewSortHelpful:
+	default:
+		return nil, ErrInvalidReview
+	}
+
+	reviews, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReviewPage{
+		Items:  reviews,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// currentCustomer возвращает профиль текущего пользователя; заблокированные клиенты не могут оставлять отзывы
+func (s *ReviewService) currentCustomer(ctx context.Context) (*models.Customer, error) {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customers.GetByID(ctx, identity.UserID)
+	if errors.Is(err, repository.ErrCustomerNotFound) {
+		return nil, ErrCustomerProfileMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	if customer.Blocked {
+		return nil, ErrCustomerBlocked
+	}
+	return customer, nil
+}
+
+func (s *ReviewService) recordAudit(ctx context.Context, id int64, action string, before, after *models.Review) error {
+	entry, err := newAuditEntry(ctx, auditEntityReview, id, action, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
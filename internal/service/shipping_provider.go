@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"path"
+	"shop-api/internal/models"
+	"slices"
+	"strings"
+)
+
+const tableRateProviderName = "table_rate"
+
+// ShippingRateProvider возвращает доступные способы доставки и их стоимость для отправления.
+// Позволяет подключить расчет тарифов службы доставки наряду со встроенными таблицами.
+type ShippingRateProvider interface {
+	Rates(ctx context.Context, shipment *models.Shipment) ([]models.ShippingRate, error)
+}
+
+// ShippingZoneSource возвращает зоны доставки со способами
+type ShippingZoneSource interface {
+	GetZones(ctx context.Context) ([]*models.ShippingZone, error)
+}
+
+// TableRateProvider рассчитывает стоимость по табличным тарифам первой подходящей зоны
+type TableRateProvider struct {
+	zones ShippingZoneSource
+}
+
+func NewTableRateProvider(zones ShippingZoneSource) *TableRateProvider {
+	return &TableRateProvider{zones: zones}
+}
+
+func (p *TableRateProvider) Rates(ctx context.Context, shipment *models.Shipment) ([]models.ShippingRate, error) {
+	zones, err := p.zones.GetZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, zone := range zones {
+		if !zoneMatches(zone, shipment.Destination) {
+			continue
+		}
+
+		var rates []models.ShippingRate
+		for _, method := range zone.Methods {
+			if !method.Active {
+				continue
+			}
+			cost, ok := methodCost(method, shipment)
+			if !ok {
+				continue
+			}
+			rates = append(rates, models.ShippingRate{
+				MethodID: method.ID,
+				Provider: tableRateProviderName,
+				Name:     method.Name,
+				Type:     method.Type,
+				Cost:     cost,
+			})
+		}
+		return rates, nil
+	}
+	return nil, nil
+}
+
+// methodCost возвращает стоимость доставки способом или false, если способ недоступен
+func methodCost(method *models.ShippingMethod, shipment *models.Shipment) (float64, bool) {
+	switch method.Type {
+	case models.ShippingFlatRate:
+		return roundMoney(method.Cost), true
+
+	case models.ShippingWeightBased:
+		weight := shipment.WeightKg
+		// Объемный вес учитывается, если он больше фактического
+		if method.VolumetricDivisor > 0 {
+			weight = max(weight, shipment.VolumeCm3/float64(method.VolumetricDivisor))
+		}
+		for _, rate := range method.WeightRates {
+			if weight <= rate.MaxWeightKg {
+				return roundMoney(rate.Cost), true
+			}
+		}
+		return 0, false
+
+	case models.ShippingFreeThreshold:
+		return 0, shipment.Subtotal >= method.Threshold
+	}
+	return 0, false
+}
+
+func zoneMatches(zone *models.ShippingZone, destination models.Destination) bool {
+	if !slices.ContainsFunc(zone.Countries, func(country string) bool {
+		return strings.EqualFold(country, destination.Country)
+	}) {
+		return false
+	}
+	if len(zone.PostalPatterns) == 0 {
+		return true
+	}
+
+	postalCode := normalizePostalCode(destination.PostalCode)
+	if postalCode == "" {
+		return false
+	}
+	for _, pattern := range zone.PostalPatterns {
+		if postalCodeMatches(normalizePostalCode(pattern), postalCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// postalCodeMatches поддерживает маски с * и ? и числовые диапазоны вида "10000..19999".
+// Диапазон сравнивается только с цифровыми индексами той же длины, что и его границы.
+func postalCodeMatches(pattern, postalCode string) bool {
+	if from, to, ok := strings.Cut(pattern, ".."); ok {
+		if len(from) != len(to) || !isDigits(from) || !isDigits(to) {
+			return false
+		}
+		return len(postalCode) == len(from) && isDigits(postalCode) && postalCode >= from && postalCode <= to
+	}
+
+	matched, err := path.Match(pattern, postalCode)
+	return err == nil && matched
+}
+
+// validPostalPattern проверяет шаблон зоны. Диапазон записывается только через "..":
+// шаблон "10000-19999" отклоняется, иначе он сравнивался бы буквально, а не как диапазон
+func validPostalPattern(pattern string) bool {
+	if from, to, ok := strings.Cut(pattern, ".."); ok {
+		return len(from) == len(to) && isDigits(from) && isDigits(to) && from <= to
+	}
+	if from, to, ok := strings.Cut(pattern, "-"); ok && len(from) == len(to) && isDigits(from) && isDigits(to) {
+		return false
+	}
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+func normalizePostalCode(postalCode string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postalCode), " ", ""))
+}
@@ -0,0 +1,58 @@
+package service
+
+import "testing"
+
+func TestPostalCodeMatches(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		postalCode string
+		want       bool
+	}{
+		{"75*", "75008", true},
+		{"75*", "76008", false},
+		{"SW1A?AA", "SW1A1AA", true},
+		{"SW1A?AA", "SW1A1AB", false},
+		{"10000..19999", "10000", true},
+		{"10000..19999", "15432", true},
+		{"10000..19999", "19999", true},
+		{"10000..19999", "20000", false},
+		{"10000..19999", "9999", false},
+		{"10000..19999", "123456", false},
+		{"10000..19999", "1500A", false},
+		{"01000..01999", "01500", true},
+		{"01000..01999", "1500", false},
+		{"1000-001", "1000-001", true},
+		{"1000-001", "1000-002", false},
+		{"10000-19999", "15000", false},
+		{"1000..99", "500", false},
+		{"A..B", "A", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.postalCode, func(t *testing.T) {
+			if got := postalCodeMatches(tt.pattern, tt.postalCode); got != tt.want {
+				t.Errorf("postalCodeMatches(%q, %q) = %v, want %v", tt.pattern, tt.postalCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidPostalPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"75*", true},
+		{"1000-001", true},
+		{"10000..19999", true},
+		{"10000-19999", false},
+		{"19999..10000", false},
+		{"1000..99", false},
+		{"A..B", false},
+		{"[", false},
+	}
+	for _, tt := range tests {
+		if got := validPostalPattern(tt.pattern); got != tt.want {
+			t.Errorf("validPostalPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
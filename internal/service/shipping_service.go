@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"sort"
+	"strings"
+)
+
+const auditEntityShippingZone = "shipping_zone"
+
+var (
+	ErrInvalidShippingZone       = errors.New("invalid shipping zone")
+	ErrShippingMethodUnavailable = errors.New("shipping method is not available for destination")
+)
+
+type ShippingService struct {
+	repo      repository.ShippingRepository
+	products  *ProductService
+	providers []ShippingRateProvider
+	audit     repository.AuditRepository
+	tx        repository.Transactor
+}
+
+func NewShippingService(repo repository.ShippingRepository, products *ProductService, audit repository.AuditRepository, tx repository.Transactor, providers ...ShippingRateProvider) *ShippingService {
+	return &ShippingService{
+		repo:      repo,
+		products:  products,
+		providers: providers,
+		audit:     audit,
+		tx:        tx,
+	}
+}
+
+// CartRates возвращает доступные способы доставки для корзины, отсортированные по стоимости
+func (s *ShippingService) CartRates(ctx context.Context, req *models.ShippingRatesRequest) ([]models.ShippingRate, error) {
+	cart, err := buildCart(ctx, s.products, req.Items, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.Rates(ctx, cart, req.Destination)
+}
+
+func (s *ShippingService) Rates(ctx context.Context, cart *models.Cart, destination models.Destination) ([]models.ShippingRate, error) {
+	if destination.Country == "" {
+		return nil, ErrInvalidCart
+	}
+
+	shipment := newShipment(cart, destination)
+	rates := []models.ShippingRate{}
+	for _, provider := range s.providers {
+		providerRates, err := provider.Rates(ctx, shipment)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, providerRates...)
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Cost < rates[j].Cost })
+	return rates, nil
+}
+
+// MethodCost возвращает стоимость доставки корзины выбранным способом
+func (s *ShippingService) MethodCost(ctx context.Context, cart *models.Cart, destination models.Destination, methodID int64) (float64, error) {
+	rates, err := s.Rates(ctx, cart, destination)
+	if err != nil {
+		return 0, err
+	}
+	for _, rate := range rates {
+		if rate.MethodID == methodID {
+			return rate.Cost, nil
+		}
+	}
+	return 0, ErrShippingMethodUnavailable
+}
+
+func (s *ShippingService) GetZones(ctx context.Context) ([]*models.ShippingZone, error) {
+	return s.repo.GetZones(ctx)
+}
+
+func (s *ShippingService) CreateZone(ctx context.Context, req *models.ShippingZoneRequest) (*models.ShippingZone, error) {
+	zone, err := newShippingZone(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range zone.Methods {
+		method.ID = 0
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateZone(ctx, zone); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, zone.ID, models.AuditActionCreate, nil, zone)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *ShippingService) UpdateZone(ctx context.Context, id int64, req *models.ShippingZoneRequest) (*models.ShippingZone, error) {
+	zone, err := newShippingZone(req)
+	if err != nil {
+		return nil, err
+	}
+	zone.ID = id
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetZone(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdateZone(ctx, zone); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, zone)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *ShippingService) DeleteZone(ctx context.Context, id int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetZone(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteZone(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, before, nil)
+	})
+}
+
+func (s *ShippingService) recordAudit(ctx context.Context, id int64, action string, before, after *models.ShippingZone) error {
+	entry, err := newAuditEntry(ctx, auditEntityShippingZone, id, action, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+func newShipment(cart *models.Cart, destination models.Destination) *models.Shipment {
+	shipment := &models.Shipment{
+		Destination: destination,
+		Subtotal:    roundMoney(cart.Subtotal()),
+	}
+	for _, item := range cart.Items {
+		shipment.WeightKg += item.WeightKg * float64(item.Quantity)
+		shipment.VolumeCm3 += item.VolumeCm3 * float64(item.Quantity)
+	}
+	return shipment
+}
+
+func newShippingZone(req *models.ShippingZoneRequest) (*models.ShippingZone, error) {
+	if strings.TrimSpace(req.Name) == "" || len(req.Countries) == 0 {
+		return nil, ErrInvalidShippingZone
+	}
+
+	zone := &models.ShippingZone{
+		Name:           req.Name,
+		PostalPatterns: []string{},
+		Priority:       req.Priority,
+		Methods:        []*models.ShippingMethod{},
+	}
+	for _, country := range req.Countries {
+		zone.Countries = append(zone.Countries, normalizeCountry(country))
+	}
+	for _, pattern := range req.PostalPatterns {
+		pattern = normalizePostalCode(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !validPostalPattern(pattern) {
+			return nil, ErrInvalidShippingZone
+		}
+		zone.PostalPatterns = append(zone.PostalPatterns, pattern)
+	}
+
+	seen := make(map[int64]bool)
+	for _, m := range req.Methods {
+		if m.ID != 0 {
+			if seen[m.ID] {
+				return nil, ErrInvalidShippingZone
+			}
+			seen[m.ID] = true
+		}
+		method := &models.ShippingMethod{
+			ID:                m.ID,
+			Name:              m.Name,
+			Type:              m.Type,
+			Cost:              m.Cost,
+			Threshold:         m.Threshold,
+			WeightRates:       m.WeightRates,
+			VolumetricDivisor: m.VolumetricDivisor,
+			Active:            m.Active == nil || *m.Active,
+		}
+		if strings.TrimSpace(method.Name) == "" || method.Cost < 0 || method.Threshold < 0 || method.VolumetricDivisor < 0 {
+			return nil, ErrInvalidShippingZone
+		}
+
+		switch method.Type {
+		case models.ShippingFlatRate, models.ShippingFreeThreshold:
+		case models.ShippingWeightBased:
+			if len(method.WeightRates) == 0 {
+				return nil, ErrInvalidShippingZone
+			}
+			sort.Slice(method.WeightRates, func(i, j int) bool {
+				return method.WeightRates[i].MaxWeightKg < method.WeightRates[j].MaxWeightKg
+			})
+		default:
+			return nil, ErrInvalidShippingZone
+		}
+		if method.WeightRates == nil {
+			method.WeightRates = []models.WeightRate{}
+		}
+		zone.Methods = append(zone.Methods, method)
+	}
+	return zone, nil
+}
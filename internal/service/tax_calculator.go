@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"shop-api/internal/models"
+	"strings"
+)
+
+// TaxCalculator рассчитывает налоги для корзины или заказа.
+// Позволяет заменить встроенный расчет внешним налоговым сервисом.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, req *models.TaxRequest) (*models.TaxResult, error)
+}
+
+// TaxRateSource возвращает ставки налога для страны
+type TaxRateSource interface {
+	GetByCountry(ctx context.Context, country string) ([]*models.TaxRate, error)
+}
+
+// TableTaxCalculator - встроенный расчет налогов по таблице ставок
+type TableTaxCalculator struct {
+	rates            TaxRateSource
+	pricesIncludeTax bool
+	rounding         string
+}
+
+func NewTableTaxCalculator(rates TaxRateSource, pricesIncludeTax bool, rounding string) *TableTaxCalculator {
+	if rounding != models.TaxRoundingPerOrder {
+		rounding = models.TaxRoundingPerLine
+	}
+	return &TableTaxCalculator{
+		rates:            rates,
+		pricesIncludeTax: pricesIncludeTax,
+		rounding:         rounding,
+	}
+}
+
+func (c *TableTaxCalculator) Calculate(ctx context.Context, req *models.TaxRequest) (*models.TaxResult, error) {
+	rates, err := c.rates.GetByCountry(ctx, normalizeCountry(req.Destination.Country))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]models.TaxableLine, 0, len(req.Lines)+1)
+	lines = append(lines, req.Lines...)
+	if req.ShippingAmount > 0 {
+		lines = append(lines, models.TaxableLine{TaxClass: models.TaxClassShipping, Amount: req.ShippingAmount})
+	}
+
+	result := &models.TaxResult{
+		PricesIncludeTax: c.pricesIncludeTax,
+		Lines:            make([]models.TaxLine, 0, len(lines)),
+	}
+
+	var totalAmount, totalTax float64
+	for _, line := range lines {
+		rate := resolveTaxRate(rates, req.Destination.Region, line.TaxClass)
+
+		tax := line.Amount * rate / 100
+		if c.pricesIncludeTax {
+			tax = line.Amount - line.Amount/(1+rate/100)
+		}
+		totalAmount += line.Amount
+		totalTax += tax
+
+		taxLine := models.TaxLine{
+			ProductID: line.ProductID,
+			TaxClass:  line.TaxClass,
+			Rate:      rate,
+			TaxAmount: roundMoney(tax),
+		}
+		if c.pricesIncludeTax {
+			taxLine.GrossAmount = roundMoney(line.Amount)
+			taxLine.NetAmount = roundMoney(taxLine.GrossAmount - taxLine.TaxAmount)
+		} else {
+			taxLine.NetAmount = roundMoney(line.Amount)
+			taxLine.GrossAmount = roundMoney(taxLine.NetAmount + taxLine.TaxAmount)
+		}
+		result.Lines = append(result.Lines, taxLine)
+	}
+
+	// При округлении по строкам итог равен сумме округленных строк,
+	// при округлении по заказу округляется только итоговая сумма налога
+	if c.rounding == models.TaxRoundingPerLine {
+		totalTax = 0
+		for _, line := range result.Lines {
+			totalTax += line.TaxAmount
+		}
+	}
+	result.TotalTax = roundMoney(totalTax)
+
+	if c.pricesIncludeTax {
+		result.TotalGross = roundMoney(totalAmount)
+		result.TotalNet = roundMoney(result.TotalGross - result.TotalTax)
+	} else {
+		result.TotalNet = roundMoney(totalAmount)
+		result.TotalGross = roundMoney(result.TotalNet + result.TotalTax)
+	}
+	return result, nil
+}
+
+// resolveTaxRate выбирает наиболее точную ставку: регион перед страной,
+// затем стандартную ставку, если для класса ставка не задана
+func resolveTaxRate(rates []*models.TaxRate, region, taxClass string) float64 {
+	if taxClass == "" {
+		taxClass = models.TaxClassStandard
+	}
+
+	for _, class := range []string{taxClass, models.TaxClassStandard} {
+		var countryRate *models.TaxRate
+		for _, rate := range rates {
+			if rate.TaxClass != class {
+				continue
+			}
+			if region != "" && strings.EqualFold(rate.Region, region) {
+				return rate.Rate
+			}
+			if rate.Region == "" {
+				countryRate = rate
+			}
+		}
+		if countryRate != nil {
+			return countryRate.Rate
+		}
+	}
+	return 0
+}
+
+func normalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"shop-api/internal/models"
+)
+
+type staticTaxRates []*models.TaxRate
+
+func (s staticTaxRates) GetByCountry(ctx context.Context, country string) ([]*models.TaxRate, error) {
+	var rates []*models.TaxRate
+	for _, rate := range s {
+		if rate.Country == country {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+var testTaxRates = staticTaxRates{
+	{Country: "DE", TaxClass: models.TaxClassStandard, Rate: 20},
+	{Country: "DE", TaxClass: "reduced", Rate: 10},
+	{Country: "US", Region: "CA", TaxClass: models.TaxClassStandard, Rate: 7.25},
+	{Country: "US", TaxClass: models.TaxClassStandard, Rate: 5},
+}
+
+func taxLines(amounts ...float64) []models.TaxableLine {
+	lines := make([]models.TaxableLine, len(amounts))
+	for i, amount := range amounts {
+		lines[i] = models.TaxableLine{ProductID: int64(i + 1), TaxClass: models.TaxClassStandard, Amount: amount}
+	}
+	return lines
+}
+
+func TestTableTaxCalculator(t *testing.T) {
+	tests := []struct {
+		name             string
+		pricesIncludeTax bool
+		rounding         string
+		req              models.TaxRequest
+		wantNet          float64
+		wantTax          float64
+		wantGross        float64
+	}{
+		{
+			name:      "exclusive, rounding per line",
+			rounding:  models.TaxRoundingPerLine,
+			req:       models.TaxRequest{Destination: models.Destination{Country: "DE"}, Lines: taxLines(0.33, 0.33, 0.33)},
+			wantNet:   0.99,
+			wantTax:   0.21,
+			wantGross: 1.20,
+		},
+		{
+			name:      "exclusive, rounding per order",
+			rounding:  models.TaxRoundingPerOrder,
+			req:       models.TaxRequest{Destination: models.Destination{Country: "DE"}, Lines: taxLines(0.33, 0.33, 0.33)},
+			wantNet:   0.99,
+			wantTax:   0.20,
+			wantGross: 1.19,
+		},
+		{
+			name:             "inclusive, rounding per line",
+			pricesIncludeTax: true,
+			rounding:         models.TaxRoundingPerLine,
+			req:              models.TaxRequest{Destination: models.Destination{Country: "DE"}, Lines: taxLines(0.34, 0.34, 0.34)},
+			wantNet:          0.84,
+			wantTax:          0.18,
+			wantGross:        1.02,
+		},
+		{
+			name:             "inclusive, rounding per order",
+			pricesIncludeTax: true,
+			rounding:         models.TaxRoundingPerOrder,
+			req:              models.TaxRequest{Destination: models.Destination{Country: "DE"}, Lines: taxLines(0.34, 0.34, 0.34)},
+			wantNet:          0.85,
+			wantTax:          0.17,
+			wantGross:        1.02,
+		},
+		{
+			name:             "inclusive whole amount",
+			pricesIncludeTax: true,
+			req:              models.TaxRequest{Destination: models.Destination{Country: "DE"}, Lines: taxLines(120)},
+			wantNet:          100,
+			wantTax:          20,
+			wantGross:        120,
+		},
+		{
+			name: "tax class and shipping",
+			req: models.TaxRequest{
+				Destination:    models.Destination{Country: "de"},
+				Lines:          []models.TaxableLine{{ProductID: 1, TaxClass: "reduced", Amount: 50}},
+				ShippingAmount: 10,
+			},
+			wantNet:   60,
+			wantTax:   7,
+			wantGross: 67,
+		},
+		{
+			name:      "region rate before country rate",
+			req:       models.TaxRequest{Destination: models.Destination{Country: "US", Region: "ca"}, Lines: taxLines(100)},
+			wantNet:   100,
+			wantTax:   7.25,
+			wantGross: 107.25,
+		},
+		{
+			name:      "country rate for other regions",
+			req:       models.TaxRequest{Destination: models.Destination{Country: "US", Region: "NY"}, Lines: taxLines(100)},
+			wantNet:   100,
+			wantTax:   5,
+			wantGross: 105,
+		},
+		{
+			name:      "no rates for country",
+			req:       models.TaxRequest{Destination: models.Destination{Country: "JP"}, Lines: taxLines(100)},
+			wantNet:   100,
+			wantTax:   0,
+			wantGross: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator := NewTableTaxCalculator(testTaxRates, tt.pricesIncludeTax, tt.rounding)
+			result, err := calculator.Calculate(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			if result.TotalNet != tt.wantNet || result.TotalTax != tt.wantTax || result.TotalGross != tt.wantGross {
+				t.Errorf("totals = net %v, tax %v, gross %v; want %v, %v, %v",
+					result.TotalNet, result.TotalTax, result.TotalGross, tt.wantNet, tt.wantTax, tt.wantGross)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"strings"
+)
+
+const auditEntityTaxRate = "tax_rate"
+
+var ErrInvalidTaxRate = errors.New("invalid tax rate")
+
+type TaxService struct {
+	repo  repository.TaxRateRepository
+	audit repository.AuditRepository
+	tx    repository.Transactor
+}
+
+func NewTaxService(repo repository.TaxRateRepository, audit repository.AuditRepository, tx repository.Transactor) *TaxService {
+	return &TaxService{
+		repo:  repo,
+		audit: audit,
+		tx:    tx,
+	}
+}
+
+func (s *TaxService) GetAllRates(ctx context.Context) ([]*models.TaxRate, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *TaxService) CreateRate(ctx context.Context, req *models.TaxRateRequest) (*models.TaxRate, error) {
+	rate, err := newTaxRate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, rate); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, rate.ID, models.AuditActionCreate, nil, rate)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *TaxService) UpdateRate(ctx context.Context, id int64, req *models.TaxRateRequest) (*models.TaxRate, error) {
+	rate, err := newTaxRate(req)
+	if err != nil {
+		return nil, err
+	}
+	rate.ID = id
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, rate); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, rate)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *TaxService) DeleteRate(ctx context.Context, id int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, before, nil)
+	})
+}
+
+func (s *TaxService) recordAudit(ctx context.Context, id int64, action string, before, after *models.TaxRate) error {
+	entry, err := newAuditEntry(ctx, auditEntityTaxRate, id, action, before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+func newTaxRate(req *models.TaxRateRequest) (*models.TaxRate, error) {
+	country := normalizeCountry(req.Country)
+	if len(country) != 2 || req.Rate < 0 || req.Rate > 100 {
+		return nil, ErrInvalidTaxRate
+	}
+
+	rate := &models.TaxRate{
+		Country:  country,
+		Region:   strings.TrimSpace(req.Region),
+		TaxClass: strings.TrimSpace(req.TaxClass),
+		Rate:     req.Rate,
+		Name:     req.Name,
+	}
+	if rate.TaxClass == "" {
+		rate.TaxClass = models.TaxClassStandard
+	}
+	return rate, nil
+}
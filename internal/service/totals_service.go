@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"shop-api/internal/models"
+)
+
+// TotalsService рассчитывает итоговые суммы корзины: скидки, налоги и итог к оплате
+type TotalsService struct {
+	products   *ProductService
+	promotions *PromotionService
+	shipping   *ShippingService
+	tax        TaxCalculator
+}
+
+func NewTotalsService(products *ProductService, promotions *PromotionService, shipping *ShippingService, tax TaxCalculator) *TotalsService {
+	return &TotalsService{
+		products:   products,
+		promotions: promotions,
+		shipping:   shipping,
+		tax:        tax,
+	}
+}
+
+func (s *TotalsService) CartTotals(ctx context.Context, req *models.CartTotalsRequest) (*models.CartTotals, error) {
+	cart, err := buildCart(ctx, s.products, req.Items, req.ShippingCost)
+	if err != nil {
+		return nil, err
+	}
+	cart.Codes = req.Codes
+
+	if req.ShippingMethodID != 0 {
+		if cart.ShippingCost, err = s.shipping.MethodCost(ctx, cart, req.Destination, req.ShippingMethodID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Calculate(ctx, cart, req.Destination)
+}
+
+// Calculate применяет акции к корзине и начисляет налог на суммы после скидок
+func (s *TotalsService) Calculate(ctx context.Context, cart *models.Cart, destination models.Destination) (*models.CartTotals, error) {
+	if destination.Country == "" {
+		return nil, ErrInvalidCart
+	}
+
+	discounts, err := s.promotions.Evaluate(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+
+	lineDiscounts := make(map[int64]float64)
+	for _, discount := range discounts.Discounts {
+		for _, line := range discount.Lines {
+			lineDiscounts[line.ProductID] += line.Amount
+		}
+	}
+
+	taxReq := &models.TaxRequest{
+		Destination:    destination,
+		ShippingAmount: roundMoney(cart.ShippingCost - discounts.ShippingDiscount),
+	}
+	for _, item := range cart.Items {
+		amount := roundMoney(item.UnitPrice*float64(item.Quantity) - lineDiscounts[item.ProductID])
+		taxReq.Lines = append(taxReq.Lines, models.TaxableLine{
+			ProductID: item.ProductID,
+			TaxClass:  item.TaxClass,
+			Amount:    amount,
+		})
+	}
+
+	tax, err := s.tax.Calculate(ctx, taxReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CartTotals{
+		Discounts: discounts,
+		Tax:       tax,
+		Total:     tax.TotalGross,
+	}, nil
+}
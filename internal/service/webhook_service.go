@@ -0,0 +1,510 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	auditEntityWebhookSubscription = "webhook_subscription"
+
+	webhookEventHeader    = "X-Webhook-Event"
+	webhookDeliveryHeader = "X-Webhook-Delivery"
+
+	webhookSecretBytes    = 24
+	webhookRequestTimeout = 10 * time.Second
+	webhookBatchSize      = 50
+	webhookLease          = time.Minute
+	// Попыток на одну доставку, после чего она помечается неуспешной
+	webhookMaxAttempts = 10
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = 6 * time.Hour
+	// Подписка отключается после стольких неудачных попыток подряд
+	webhookDisableAfter = 20
+	// Тело ответа подписчика читается не больше этого размера
+	webhookMaxResponseBody = 64 << 10
+
+	defaultWebhookDeliveryLimit = 20
+	maxWebhookDeliveryLimit     = 100
+)
+
+var (
+	ErrInvalidWebhookSubscription = errors.New("invalid webhook subscription")
+	ErrWebhookAddressForbidden    = errors.New("webhook address is not public")
+)
+
+// Диапазоны, не покрытые методами netip.Addr: служебные и внутренние сети провайдеров
+var webhookForbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Типы событий, на которые можно подписаться
+var webhookEventTypes = map[string]bool{
+	AllEvents:                        true,
+	models.EventProductCreated:       true,
+	models.EventProductUpdated:       true,
+	models.EventProductDeleted:       true,
+	models.EventStockChanged:         true,
+	models.EventPaymentStatusChanged: true,
+}
+
+// WebhookService управляет подписками внешних систем и доставляет им события.
+// События ставятся в очередь подписчиком шины, а отправляются фоновым обработчиком.
+type WebhookService struct {
+	repo   repository.WebhookRepository
+	audit  repository.AuditRepository
+	tx     repository.Transactor
+	client *http.Client
+	logger *slog.Logger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, audit repository.AuditRepository, tx repository.Transactor, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		audit:  audit,
+		tx:     tx,
+		logger: logger,
+		client: &http.Client{
+			Timeout:   webhookRequestTimeout,
+			Transport: newWebhookTransport(),
+			// Перенаправление считается ошибкой доставки
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// CreateSubscription создает подписку; ответ содержит секрет для проверки подписи
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	eventTypes, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:        strings.TrimSpace(req.URL),
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     req.Active == nil || *req.Active,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, sub.ID, models.AuditActionCreate, nil, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// UpdateSubscription обновляет подписку; повторное включение сбрасывает счетчик неудач
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id int64, req *models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	eventTypes, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub *models.WebhookSubscription
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updated := *before
+		updated.URL = strings.TrimSpace(req.URL)
+		updated.EventTypes = eventTypes
+		if req.Secret != "" {
+			updated.Secret = req.Secret
+		}
+		if req.Active != nil {
+			updated.Active = *req.Active
+		}
+		if updated.Active && !before.Active {
+			updated.ConsecutiveFailures = 0
+			updated.DisabledReason = ""
+		}
+		if err := s.repo.UpdateSubscription(ctx, &updated); err != nil {
+			return err
+		}
+		sub = &updated
+		return s.recordAudit(ctx, id, models.AuditActionUpdate, before, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, id, models.AuditActionDelete, before, nil)
+	})
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]*models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+	if limit > maxWebhookDeliveryLimit {
+		limit = maxWebhookDeliveryLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, limit, offset)
+}
+
+// GetDelivery возвращает доставку вместе с журналом попыток
+func (s *WebhookService) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.AttemptLog, err = s.repo.GetAttempts(ctx, id); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Redeliver ставит доставку в очередь повторно; отправит её фоновый обработчик
+func (s *WebhookService) Redeliver(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	if err := s.repo.Redeliver(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.GetDelivery(ctx, id)
+}
+
+// HandleEvent ставит событие в очередь доставки всем подписанным на него.
+// Вызывается шиной событий; повторное событие не создает новых доставок.
+func (s *WebhookService) HandleEvent(ctx context.Context, event *models.Event) error {
+	subs, err := s.repo.GetSubscriptionsForEvent(ctx, event.Type)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:            event.ID,
+		Type:          event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Data:          event.Payload,
+		CreatedAt:     event.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, sub := range subs {
+			err := s.repo.CreateDelivery(ctx, &models.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Payload:        payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RunDeliveryWorker периодически отправляет доставки, для которых подошло время
+func (s *WebhookService) RunDeliveryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.deliverPending(ctx); err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "webhook delivery error", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WebhookService) deliverPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		deliveries, err := s.repo.ClaimDeliveries(ctx, webhookBatchSize, webhookLease)
+		if err != nil {
+			return err
+		}
+
+		subs := make(map[int64]*models.WebhookSubscription)
+		for _, delivery := range deliveries {
+			sub, ok := subs[delivery.SubscriptionID]
+			if !ok {
+				if sub, err = s.repo.GetSubscription(ctx, delivery.SubscriptionID); err != nil {
+					return err
+				}
+				subs[sub.ID] = sub
+			}
+			if err := s.deliver(ctx, sub, delivery); err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// deliver отправляет одну доставку и фиксирует результат попытки
+func (s *WebhookService) deliver(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) error {
+	// Подписка могла быть отключена неудачами предыдущих доставок этого пакета
+	if !sub.Active {
+		return s.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	start := time.Now()
+	code, sendErr := s.send(ctx, sub, delivery)
+	attempt := &models.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		DurationMs: int(time.Since(start).Milliseconds()),
+	}
+	if code != 0 {
+		attempt.ResponseCode = &code
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+
+	delivery.Attempts++
+	delivery.LastResponseCode = attempt.ResponseCode
+	delivery.LastError = attempt.Error
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddAttempt(ctx, attempt); err != nil {
+			return err
+		}
+
+		if sendErr == nil {
+			now := time.Now()
+			delivery.Status = models.WebhookDeliverySucceeded
+			delivery.DeliveredAt = &now
+			if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+			sub.ConsecutiveFailures = 0
+			return s.repo.ResetFailures(ctx, sub.ID)
+		}
+
+		if delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts-1, webhookRetryBase, webhookRetryMax))
+		}
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		failures, err := s.repo.RecordFailure(ctx, sub.ID)
+		if err != nil {
+			return err
+		}
+		sub.ConsecutiveFailures = failures
+		if failures < webhookDisableAfter {
+			return nil
+		}
+
+		sub.Active = false
+		sub.DisabledReason = fmt.Sprintf("disabled after %d consecutive failed deliveries: %s", failures, attempt.Error)
+		s.logger.WarnContext(ctx, "webhook subscription disabled", "subscription_id", sub.ID, "reason", sub.DisabledReason)
+		return s.repo.Disable(ctx, sub.ID, sub.DisabledReason)
+	})
+}
+
+// send выполняет HTTP-запрос к подписчику; успехом считается только ответ 2xx
+func (s *WebhookService) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "shop-api-webhooks")
+	req.Header.Set(webhookEventHeader, delivery.EventType)
+	req.Header.Set(webhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhookSignatureHeader, SignWebhook(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookMaxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// recordAudit пишет изменение подписки в журнал без секрета
+func (s *WebhookService) recordAudit(ctx context.Context, id int64, action string, before, after *models.WebhookSubscription) error {
+	entry, err := newAuditEntry(ctx, auditEntityWebhookSubscription, id, action, withoutSecret(before), withoutSecret(after))
+	if err != nil {
+		return err
+	}
+	return s.audit.Create(ctx, entry)
+}
+
+func withoutSecret(sub *models.WebhookSubscription) *models.WebhookSubscription {
+	if sub == nil {
+		return nil
+	}
+	copied := *sub
+	copied.Secret = ""
+	return &copied
+}
+
+// newWebhookTransport не выполняет соединения с внутренними адресами. Проверка происходит
+// при установке соединения с уже разрешенным IP, поэтому смена DNS-записи после
+// создания подписки ее не обходит. Прокси из окружения не используется по той же причине.
+func newWebhookTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: webhookRequestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressForbidden, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: webhookRequestTimeout,
+	}
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range webhookForbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWebhookRequest проверяет URL и типы событий и возвращает их без повторов
+func validateWebhookRequest(req *models.WebhookSubscriptionRequest) ([]string, error) {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, ErrInvalidWebhookSubscription
+	}
+	// Адреса, заданные IP или localhost, отклоняются сразу; имена проверяются при соединении
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrInvalidWebhookSubscription
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublicAddr(addr) {
+		return nil, ErrInvalidWebhookSubscription
+	}
+	if len(req.EventTypes) == 0 {
+		return nil, ErrInvalidWebhookSubscription
+	}
+
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	seen := make(map[string]bool)
+	for _, eventType := range req.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !webhookEventTypes[eventType] {
+			return nil, ErrInvalidWebhookSubscription
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"shop-api/internal/models"
+	"shop-api/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxWishlistNameLength = 255
+	shareTokenBytes       = 16
+)
+
+var ErrInvalidWishlist = errors.New("invalid wishlist")
+
+type WishlistService struct {
+	repo      repository.WishlistRepository
+	customers repository.CustomerRepository
+	products  *ProductService
+}
+
+func NewWishlistService(repo repository.WishlistRepository, customers repository.CustomerRepository, products *ProductService) *WishlistService {
+	return &WishlistService{
+		repo:      repo,
+		customers: customers,
+		products:  products,
+	}
+}
+
+// GetWishlists возвращает все списки текущего пользователя вместе с товарами
+func (s *WishlistService) GetWishlists(ctx context.Context) ([]*models.Wishlist, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlists, err := s.repo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadItems(ctx, wishlists...); err != nil {
+		return nil, err
+	}
+	return wishlists, nil
+}
+
+func (s *WishlistService) GetWishlist(ctx context.Context, id int64) (*models.Wishlist, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := s.repo.Get(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, s.loadItems(ctx, wishlist)
+}
+
+// GetSharedWishlist возвращает опубликованный список по токену ссылки
+func (s *WishlistService) GetSharedWishlist(ctx context.Context, token string) (*models.Wishlist, error) {
+	wishlist, err := s.repo.GetByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	// Токен не возвращается посетителям ссылки
+	wishlist.ShareToken = nil
+	return wishlist, s.loadItems(ctx, wishlist)
+}
+
+func (s *WishlistService) CreateWishlist(ctx context.Context, req *models.WishlistRequest) (*models.Wishlist, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := wishlistName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist := &models.Wishlist{
+		CustomerID: customerID,
+		Name:       name,
+		Items:      []*models.WishlistItem{},
+	}
+	if err := s.repo.Create(ctx, wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (s *WishlistService) RenameWishlist(ctx context.Context, id int64, req *models.WishlistRequest) (*models.Wishlist, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := wishlistName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist := &models.Wishlist{ID: id, CustomerID: customerID, Name: name}
+	if err := s.repo.Rename(ctx, wishlist); err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, id)
+}
+
+func (s *WishlistService) DeleteWishlist(ctx context.Context, id int64) error {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, customerID, id)
+}
+
+// AddItem добавляет продукт в список, запоминая текущую цену
+func (s *WishlistService) AddItem(ctx context.Context, id int64, req *models.WishlistItemRequest) (*models.Wishlist, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Get(ctx, customerID, id); err != nil {
+		return nil, err
+	}
+	product, err := s.products.GetProductByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddItem(ctx, id, product.ID, product.EffectivePrice); err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, id)
+}
+
+func (s *WishlistService) RemoveItem(ctx context.Context, id, productID int64) error {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.repo.Get(ctx, customerID, id); err != nil {
+		return err
+	}
+	return s.repo.RemoveItem(ctx, id, productID)
+}
+
+// Share публикует список по случайному токену; повторный вызов выдает новый токен,
+// и старая ссылка перестает работать
+func (s *WishlistService) Share(ctx context.Context, id int64) (*models.Wishlist, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := s.repo.SetShareToken(ctx, customerID, id, &token); err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, id)
+}
+
+func (s *WishlistService) Unshare(ctx context.Context, id int64) error {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetShareToken(ctx, customerID, id, nil)
+}
+
+func (s *WishlistService) GetNotifications(ctx context.Context) ([]*models.WishlistNotification, error) {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetNotifications(ctx, customerID)
+}
+
+func (s *WishlistService) MarkNotificationRead(ctx context.Context, id int64) error {
+	customerID, err := s.currentCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkNotificationRead(ctx, customerID, id)
+}
+
+// loadItems загружает товары списков с актуальными ценами; продукты всех списков
+// читаются одним запросом, удаленные продукты пропускаются
+func (s *WishlistService) loadItems(ctx context.Context, wishlists ...*models.Wishlist) error {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, wishlist := range wishlists {
+		items, err := s.repo.GetItems(ctx, wishlist.ID)
+		if err != nil {
+			return err
+		}
+		wishlist.Items = items
+		for _, item := range items {
+			if !seen[item.ProductID] {
+				seen[item.ProductID] = true
+				ids = append(ids, item.ProductID)
+			}
+		}
+	}
+
+	products, err := s.products.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, wishlist := range wishlists {
+		items := make([]*models.WishlistItem, 0, len(wishlist.Items))
+		for _, item := range wishlist.Items {
+			if product, ok := products[item.ProductID]; ok {
+				item.Product = product
+				items = append(items, item)
+			}
+		}
+		wishlist.Items = items
+	}
+	return nil
+}
+
+// currentCustomerID возвращает ID текущего пользователя; профиль для списков желаний не обязателен,
+// но заблокированный клиент доступа не получает
+func (s *WishlistService) currentCustomerID(ctx context.Context) (int64, error) {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	customer, err := s.customers.GetByID(ctx, identity.UserID)
+	if err != nil && !errors.Is(err, repository.ErrCustomerNotFound) {
+		return 0, err
+	}
+	if customer != nil && customer.Blocked {
+		return 0, ErrCustomerBlocked
+	}
+	return identity.UserID, nil
+}
+
+func wishlistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWishlistNameLength {
+		return "", ErrInvalidWishlist
+	}
+	return name, nil
+}
+
+// WishlistNotifier создает уведомления о поступлении и снижении цены товаров из списков желаний
+type WishlistNotifier struct {
+	repo repository.WishlistRepository
+}
+
+func NewWishlistNotifier(repo repository.WishlistRepository) *WishlistNotifier {
+	return &WishlistNotifier{repo: repo}
+}
+
+func (n *WishlistNotifier) ProductUpdated(ctx context.Context, before, after *models.Product) error {
+	if before.Stock <= 0 && after.Stock > 0 {
+		if err := n.repo.CreateNotifications(ctx, after.ID, models.WishlistNotificationBackInStock, nil, nil); err != nil {
+			return err
+		}
+	}
+	if after.Price < before.Price {
+		oldPrice, newPrice := before.Price, after.Price
+		if err := n.repo.CreateNotifications(ctx, after.ID, models.WishlistNotificationPriceDrop, &oldPrice, &newPrice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    old_price DECIMAL(10,2),
+    new_price DECIMAL(10,2) NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON price_history (product_id, changed_at);
+
+CREATE TABLE IF NOT EXISTS scheduled_prices (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(10,2) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    started BOOLEAN NOT NULL DEFAULT FALSE,
+    ended BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_prices_product ON scheduled_prices (product_id, starts_at);
+
+-- Начальная цена для уже существующих продуктов
+INSERT INTO price_history (product_id, old_price, new_price, source, changed_at)
+SELECT p.id, NULL, p.price, 'initial', COALESCE(p.created_at, CURRENT_TIMESTAMP)
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.product_id = p.id);
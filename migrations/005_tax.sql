@@ -0,0 +1,13 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id BIGSERIAL PRIMARY KEY,
+    country CHAR(2) NOT NULL,
+    region VARCHAR(64) NOT NULL DEFAULT '',
+    tax_class VARCHAR(32) NOT NULL DEFAULT 'standard',
+    rate DECIMAL(6,3) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (country, region, tax_class)
+);
@@ -0,0 +1,28 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_kg DECIMAL(10,3) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS length_cm DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS width_cm DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS height_cm DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    countries TEXT[] NOT NULL DEFAULT '{}',
+    postal_patterns TEXT[] NOT NULL DEFAULT '{}',
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id BIGSERIAL PRIMARY KEY,
+    zone_id BIGINT NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    cost DECIMAL(10,2) NOT NULL DEFAULT 0,
+    threshold DECIMAL(10,2) NOT NULL DEFAULT 0,
+    weight_rates JSONB NOT NULL DEFAULT '[]'::jsonb,
+    volumetric_divisor INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_zone ON shipping_methods (zone_id);
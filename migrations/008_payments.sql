@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT,
+    amount DECIMAL(10,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+    captured_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    failure_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_order ON payments (order_id);
+CREATE INDEX IF NOT EXISTS idx_payments_provider_ref ON payments (provider, provider_ref);
+
+CREATE TABLE IF NOT EXISTS payment_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    operation VARCHAR(32) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_payment ON payment_attempts (payment_id);
+-- Успешная операция с ключом идемпотентности выполняется только один раз
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_attempts_idempotency
+    ON payment_attempts (idempotency_key) WHERE success AND idempotency_key <> '';
+
+-- Обработанные события вебхуков; повторная доставка события игнорируется
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    event_id VARCHAR(255) PRIMARY KEY,
+    provider VARCHAR(32) NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
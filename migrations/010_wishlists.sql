@@ -0,0 +1,34 @@
+-- customer_id совпадает с идентификатором пользователя из контекста аутентификации
+CREATE TABLE IF NOT EXISTS wishlists (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    -- Токен публичной ссылки; NULL - список не опубликован
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (customer_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    wishlist_id BIGINT NOT NULL REFERENCES wishlists(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    added_price DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wishlist_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_items_product ON wishlist_items (product_id);
+
+CREATE TABLE IF NOT EXISTS wishlist_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    old_price DECIMAL(10,2),
+    new_price DECIMAL(10,2),
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_notifications_customer ON wishlist_notifications (customer_id, created_at DESC);
@@ -0,0 +1,21 @@
+-- Связи товаров, заданные вручную
+CREATE TABLE IF NOT EXISTS product_relations (
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    related_product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, related_product_id, type),
+    CHECK (product_id <> related_product_id)
+);
+
+-- Похожие товары, рассчитываемые фоновой задачей
+CREATE TABLE IF NOT EXISTS product_similarities (
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    similar_product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, similar_product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_similarities_score ON product_similarities (product_id, score DESC);
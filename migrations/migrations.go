@@ -0,0 +1,33 @@
+// Package migrations встраивает SQL-миграции в бинарник, чтобы проверка готовности
+// знала, до какой версии должна быть обновлена схема базы.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// LatestVersion возвращает номер последней миграции - числовой префикс имени файла (015_...)
+func LatestVersion() int {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return 0
+	}
+
+	latest := 0
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		if version, err := strconv.Atoi(prefix); err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"io/fs"
+	"regexp"
+	"testing"
+)
+
+var migrationName = regexp.MustCompile(`^\d{3}_[a-z0-9_]+\.sql$`)
+
+func TestMigrationNames(t *testing.T) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !migrationName.MatchString(entry.Name()) {
+			t.Errorf("migration %q does not match NNN_name.sql, its version would be ignored", entry.Name())
+		}
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	if got := LatestVersion(); got < 14 {
+		t.Fatalf("LatestVersion() = %d, want at least 14", got)
+	}
+}
@@ -1,36 +1,438 @@
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Config - конфигурация сервиса. Значения берутся по возрастанию приоритета: значения по умолчанию,
+// файл конфигурации (YAML или TOML), переменные окружения, флаги командной строки.
+//
+// Теги полей: yaml - ключ в файле (флаг называется по пути ключа, например -database.port),
+// env - переменная окружения, secret - значение скрывается при выводе и может быть прочитано
+// из файла, указанного в переменной <env>_FILE, reload - значение применяется без перезапуска.
 type Config struct {
-	DBHost     string
-	DBPort     int
-	DBUser     string
-	DBPassword string
-	DBName     string
-	ServerPort string
+	// Файл, из которого загружена конфигурация
+	File string `yaml:"-"`
+
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Health      HealthConfig      `yaml:"health"`
+	Events      EventsConfig      `yaml:"events"`
+	Payments    PaymentsConfig    `yaml:"payments"`
+	Tax         TaxConfig         `yaml:"tax"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Security    SecurityConfig    `yaml:"security"`
+	Features    FeaturesConfig    `yaml:"features"`
+	Reload      ReloadConfig      `yaml:"reload"`
+}
+
+type ServerConfig struct {
+	Host string `yaml:"host" env:"SERVER_HOST"`
+	Port int    `yaml:"port" env:"SERVER_PORT"`
+	// Адрес (host:port) в документации Swagger; пустое значение - адрес, с которого открыт Swagger UI
+	PublicHost      string        `yaml:"public_host" env:"SERVER_PUBLIC_HOST"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// Сколько /readyz отвечает 503 перед остановкой сервера, чтобы балансировщик успел убрать экземпляр
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// Подсети (CIDR) или адреса прокси, которым доверяются X-Forwarded-For и X-Real-IP
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+func (c ServerConfig) Addr() string {
+	return c.Host + ":" + strconv.Itoa(c.Port)
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+
+	MaxConns        int           `yaml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns        int           `yaml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+
+	// Реплики для чтения каталога (host или host:port); пользователь, пароль и база те же
+	Replicas []string `yaml:"replicas" env:"DB_REPLICAS"`
+	// Реплика с большим отставанием не используется, пока не догонит основной сервер
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env:"DB_REPLICA_MAX_LAG"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+	// Сколько клиент после изменяющего запроса читает с основного сервера, чтобы видеть свои изменения
+	ReadYourWritesWindow time.Duration `yaml:"read_your_writes_window" env:"DB_READ_YOUR_WRITES_WINDOW"`
+}
+
+// ConnString возвращает строку подключения; имя пользователя и пароль экранируются
+func (c DatabaseConfig) ConnString() string {
+	return c.connString(c.Host + ":" + strconv.Itoa(c.Port))
+}
+
+// ReplicaConnString возвращает строку подключения к реплике; без порта используется порт основного сервера
+func (c DatabaseConfig) ReplicaConnString(replica string) string {
+	if _, _, err := net.SplitHostPort(replica); err != nil {
+		replica = net.JoinHostPort(replica, strconv.Itoa(c.Port))
+	}
+	return c.connString(replica)
+}
+
+func (c DatabaseConfig) connString(host string) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   host,
+		Path:   "/" + c.Name,
+	}
+	q := url.Values{}
+	q.Set("sslmode", c.SSLMode)
+	q.Set("pool_max_conns", strconv.Itoa(c.MaxConns))
+	q.Set("pool_min_conns", strconv.Itoa(c.MinConns))
+	q.Set("pool_max_conn_lifetime", c.MaxConnLifetime.String())
+	q.Set("pool_max_conn_idle_time", c.MaxConnIdleTime.String())
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr" env:"REDIS_ADDR"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
+}
+
+// AuthConfig - проверка токенов доступа, которые выдает сервис входа
+type AuthConfig struct {
+	// Общий с сервисом входа секрет подписи HS256, не короче 32 байт
+	JWTSecret string `yaml:"jwt_secret" env:"AUTH_JWT_SECRET" secret:"true"`
+	// Пустые значения не проверяются
+	Issuer   string `yaml:"issuer" env:"AUTH_ISSUER"`
+	Audience string `yaml:"audience" env:"AUTH_AUDIENCE"`
+}
+
+type CacheConfig struct {
+	ProductsTTL time.Duration `yaml:"products_ttl" env:"CACHE_PRODUCTS_TTL" reload:"true"`
+	// Предохранитель Redis размыкается после стольких ошибок подряд
+	BreakerThreshold int `yaml:"breaker_threshold" env:"CACHE_BREAKER_THRESHOLD"`
+	// Как часто проверяется Redis, пока предохранитель разомкнут
+	BreakerProbeInterval time.Duration `yaml:"breaker_probe_interval" env:"CACHE_BREAKER_PROBE_INTERVAL"`
+}
+
+type LoggingConfig struct {
+	// json или text
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+	// debug, info, warn или error
+	Level string `yaml:"level" env:"LOG_LEVEL" reload:"true"`
+}
+
+type TracingConfig struct {
+	// none, stdout или otlp (адрес в OTEL_EXPORTER_OTLP_ENDPOINT)
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type MetricsConfig struct {
+	// Адрес отдельного сервера метрик; пустое значение - /metrics на основном сервере
+	Addr string `yaml:"addr" env:"METRICS_ADDR,allowempty"`
+	// Продукты с остатком не больше порога считаются заканчивающимися
+	LowStockThreshold int `yaml:"low_stock_threshold" env:"LOW_STOCK_THRESHOLD" reload:"true"`
+}
+
+type HealthConfig struct {
+	// Таймаут проверок зависимостей в /readyz
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+}
+
+type EventsConfig struct {
+	// Внешние приемники событий outbox: redis, nats. Шина внутри процесса подключена всегда.
+	Sinks             []string `yaml:"sinks" env:"EVENT_SINKS"`
+	RedisStream       string   `yaml:"redis_stream" env:"EVENT_REDIS_STREAM"`
+	NATSURL           string   `yaml:"nats_url" env:"NATS_URL"`
+	NATSSubjectPrefix string   `yaml:"nats_subject_prefix" env:"NATS_SUBJECT_PREFIX"`
+}
+
+type PaymentsConfig struct {
+	// fake (для разработки) или gateway
+	Provider      string `yaml:"provider" env:"PAYMENT_PROVIDER"`
+	GatewayURL    string `yaml:"gateway_url" env:"PAYMENT_GATEWAY_URL"`
+	GatewayAPIKey string `yaml:"gateway_api_key" env:"PAYMENT_GATEWAY_API_KEY" secret:"true"`
+	// Секрет для проверки подписи вебхуков провайдера
+	WebhookSecret string `yaml:"webhook_secret" env:"PAYMENT_WEBHOOK_SECRET" secret:"true"`
+	Currency      string `yaml:"currency" env:"PAYMENT_CURRENCY"`
+}
+
+type TaxConfig struct {
+	// Цены в каталоге указаны с учетом налога
+	PricesIncludeTax bool `yaml:"prices_include_tax" env:"TAX_PRICES_INCLUDE_TAX"`
+	// line (по строкам) или order (по заказу)
+	Rounding string `yaml:"rounding" env:"TAX_ROUNDING"`
+}
+
+type IdempotencyConfig struct {
+	// redis или postgres
+	Store string `yaml:"store" env:"IDEMPOTENCY_STORE"`
+	// Сколько хранится ответ на запрос с Idempotency-Key
+	Retention time.Duration `yaml:"retention" env:"IDEMPOTENCY_RETENTION"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED" reload:"true"`
+	// redis (общее для экземпляров) или memory
+	Store string `yaml:"store" env:"RATE_LIMIT_STORE"`
+	// Правила через ";", формат описан в ratelimit.ParseRules
+	Rules string `yaml:"rules" env:"RATE_LIMIT_RULES" reload:"true"`
+	// Выданные ключи партнеров: правила с key=api_key применяются только к ним
+	APIKeys []string `yaml:"api_keys" env:"RATE_LIMIT_API_KEYS" secret:"true" reload:"true"`
+}
+
+type CORSConfig struct {
+	// Полный адрес, "*" или https://*.example.com для поддоменов; "*" нельзя сочетать с AllowCredentials
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type SecurityConfig struct {
+	// HSTS отправляется при значении больше нуля; включать, только если API доступен по HTTPS
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" env:"HSTS_MAX_AGE"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains" env:"HSTS_INCLUDE_SUBDOMAINS"`
+	ReferrerPolicy        string        `yaml:"referrer_policy" env:"REFERRER_POLICY"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy" env:"CONTENT_SECURITY_POLICY"`
+	// CSP для Swagger UI, которому нужны встроенные скрипты и стили
+	SwaggerContentSecurityPolicy string `yaml:"swagger_content_security_policy" env:"SWAGGER_CONTENT_SECURITY_POLICY"`
 }
 
-func LoadConfig() *Config {
-	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
+// FeaturesConfig включает необязательные части сервиса
+type FeaturesConfig struct {
+	SwaggerUI      bool `yaml:"swagger_ui" env:"FEATURE_SWAGGER_UI"`
+	PriceScheduler bool `yaml:"price_scheduler" env:"FEATURE_PRICE_SCHEDULER"`
+	SimilarityJob  bool `yaml:"similarity_job" env:"FEATURE_SIMILARITY_JOB"`
+	// Доставка исходящих вебхуков; подписки можно настраивать и при выключенной доставке
+	WebhookDelivery bool `yaml:"webhook_delivery" env:"FEATURE_WEBHOOK_DELIVERY"`
+}
+
+// ReloadConfig управляет перечитыванием файла конфигурации без перезапуска
+type ReloadConfig struct {
+	// Как часто проверяется изменение файла; 0 - только по SIGHUP
+	WatchInterval time.Duration `yaml:"watch_interval" env:"CONFIG_WATCH_INTERVAL"`
+}
+
+// Строже всего ограничены входы и платежи, затем изменяющие запросы, затем остальной API
+const defaultRateLimitRules = "name=auth auth=failed path=/api/* limit=10/1m burst=5 key=ip;" +
+	"name=payments methods=POST path=/api/payments limit=20/1m;" +
+	"name=write methods=POST,PUT,DELETE path=/api/* limit=120/1m burst=30;" +
+	"name=default path=/api/* limit=600/1m burst=100"
+
+const defaultSwaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
 
+func Default() *Config {
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     dbPort,
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "postgres"),
-		DBName:     getEnv("DB_NAME", "shop"),
-		ServerPort: getEnv("SERVER_PORT", "8080"),
+		Server: ServerConfig{
+			Host:               "0.0.0.0",
+			Port:               8080,
+			ReadTimeout:        15 * time.Second,
+			WriteTimeout:       15 * time.Second,
+			IdleTimeout:        60 * time.Second,
+			ShutdownTimeout:    5 * time.Second,
+			ShutdownDrainDelay: 5 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "shop",
+			SSLMode:         "prefer",
+			MaxConns:        10,
+			MinConns:        0,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+
+			ReplicaMaxLag:        5 * time.Second,
+			ReplicaCheckInterval: 2 * time.Second,
+			ReadYourWritesWindow: 5 * time.Second,
+		},
+		Redis: RedisConfig{
+			Addr: "127.0.0.1:6379",
+		},
+		Cache: CacheConfig{
+			ProductsTTL:          5 * time.Minute,
+			BreakerThreshold:     5,
+			BreakerProbeInterval: 5 * time.Second,
+		},
+		Logging: LoggingConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		Metrics: MetricsConfig{
+			Addr:              ":9090",
+			LowStockThreshold: 5,
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+		},
+		Events: EventsConfig{
+			RedisStream:       "shop:events",
+			NATSURL:           "nats://127.0.0.1:4222",
+			NATSSubjectPrefix: "shop.events",
+		},
+		Payments: PaymentsConfig{
+			Provider: "fake",
+			Currency: "RUB",
+		},
+		Tax: TaxConfig{
+			PricesIncludeTax: true,
+			Rounding:         "line",
+		},
+		Idempotency: IdempotencyConfig{
+			Store:     "redis",
+			Retention: 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "redis",
+			Rules:   defaultRateLimitRules,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "Idempotency-Key", "X-API-Key"},
+			ExposedHeaders: []string{"X-Request-Id", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining",
+				"RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
+			MaxAge: 10 * time.Minute,
+		},
+		Security: SecurityConfig{
+			ReferrerPolicy:               "strict-origin-when-cross-origin",
+			ContentSecurityPolicy:        "default-src 'none'; frame-ancestors 'none'",
+			SwaggerContentSecurityPolicy: defaultSwaggerCSP,
+		},
+		Features: FeaturesConfig{
+			SwaggerUI:       true,
+			PriceScheduler:  true,
+			SimilarityJob:   true,
+			WebhookDelivery: true,
+		},
+		Reload: ReloadConfig{
+			WatchInterval: 10 * time.Second,
+		},
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s: must be one of %v, got %q", name, allowed, value))
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port: must be between 1 and 65535")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout: must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout: must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout: must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+	check(c.Server.ShutdownDrainDelay >= 0, "server.shutdown_drain_delay: must not be negative")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, errPrefix := netip.ParsePrefix(proxy)
+		_, errAddr := netip.ParseAddr(proxy)
+		check(errPrefix == nil || errAddr == nil, "server.trusted_proxies: invalid CIDR or address %q", proxy)
 	}
-	return value
+
+	check(c.Database.Host != "", "database.host: required")
+	check(c.Database.Port > 0 && c.Database.Port < 65536, "database.port: must be between 1 and 65535")
+	check(c.Database.User != "", "database.user: required")
+	check(c.Database.Name != "", "database.name: required")
+	oneOf("database.sslmode", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	check(c.Database.MaxConns > 0, "database.max_conns: must be positive")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns: must be between 0 and database.max_conns")
+	check(c.Database.MaxConnLifetime > 0, "database.max_conn_lifetime: must be positive")
+	check(c.Database.MaxConnIdleTime > 0, "database.max_conn_idle_time: must be positive")
+	for _, replica := range c.Database.Replicas {
+		check(replica != "" && !strings.Contains(replica, "/"), "database.replicas: invalid address %q", replica)
+	}
+	check(c.Database.ReplicaMaxLag > 0, "database.replica_max_lag: must be positive")
+	check(c.Database.ReplicaCheckInterval > 0, "database.replica_check_interval: must be positive")
+	check(c.Database.ReadYourWritesWindow >= 0, "database.read_your_writes_window: must not be negative")
+
+	check(c.Redis.Addr != "", "redis.addr: required")
+	check(c.Redis.DB >= 0, "redis.db: must not be negative")
+
+	check(len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret: required, at least 32 bytes")
+
+	check(c.Cache.ProductsTTL > 0, "cache.products_ttl: must be positive")
+	check(c.Cache.BreakerThreshold > 0, "cache.breaker_threshold: must be positive")
+	check(c.Cache.BreakerProbeInterval > 0, "cache.breaker_probe_interval: must be positive")
+
+	oneOf("logging.format", c.Logging.Format, "json", "text")
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Logging.Level)) == nil, "logging.level: unknown level %q", c.Logging.Level)
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be between 0 and 1")
+
+	check(c.Metrics.LowStockThreshold >= 0, "metrics.low_stock_threshold: must not be negative")
+	check(c.Health.CheckTimeout > 0, "health.check_timeout: must be positive")
+
+	for _, sink := range c.Events.Sinks {
+		oneOf("events.sinks", sink, "redis", "nats")
+	}
+
+	oneOf("payments.provider", c.Payments.Provider, "fake", "gateway")
+	if c.Payments.Provider == "gateway" {
+		check(c.Payments.GatewayURL != "", "payments.gateway_url: required for the gateway provider")
+		check(c.Payments.GatewayAPIKey != "", "payments.gateway_api_key: required for the gateway provider")
+	}
+	check(len(c.Payments.Currency) == 3, "payments.currency: must be a 3-letter ISO 4217 code")
+
+	oneOf("tax.rounding", c.Tax.Rounding, "line", "order")
+
+	oneOf("idempotency.store", c.Idempotency.Store, "redis", "postgres")
+	check(c.Idempotency.Retention > 0, "idempotency.retention: must be positive")
+
+	oneOf("rate_limit.store", c.RateLimit.Store, "redis", "memory")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(!(origin == "*" && c.CORS.AllowCredentials), "cors.allow_credentials: cannot be combined with origin \"*\"")
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age: must not be negative")
+	check(c.Security.HSTSMaxAge >= 0, "security.hsts_max_age: must not be negative")
+	check(c.Reload.WatchInterval >= 0, "reload.watch_interval: must not be negative")
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrHelp возвращается, если запрошена справка по флагам
+var ErrHelp = flag.ErrHelp
+
+// Load собирает конфигурацию из файла, окружения и флагов args и проверяет её.
+// Файл задается флагом -config или переменной CONFIG_FILE. Все ошибки возвращаются вместе.
+func Load(args []string, output io.Writer) (*Config, error) {
+	cfg := Default()
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	flags := flag.NewFlagSet("shop-api", flag.ContinueOnError)
+	flags.SetOutput(output)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "config file (.yaml, .yml or .toml)")
+	flagValues := make(map[string]string)
+	for _, f := range fields {
+		usage := "env " + f.env
+		if f.secret {
+			usage += " or " + f.env + "_FILE"
+		}
+		flags.Func(f.path, usage, func(value string) error {
+			flagValues[f.path] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+		cfg.File = *configFile
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if err := f.applyEnv(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, f := range fields {
+		if value, ok := flagValues[f.path]; ok {
+			if err := setValue(f.value, value); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", f.path, err))
+			}
+		}
+	}
+	// Ошибки разбора и проверки возвращаются вместе, чтобы их можно было исправить за один раз
+	if err := errors.Join(append(errs, cfg.Validate())...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		// TOML приводится к YAML, чтобы ключи описывались одними тегами
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(raw); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// field - настраиваемое поле конфигурации
+type field struct {
+	path       string
+	env        string
+	allowEmpty bool
+	secret     bool
+	reload     bool
+	value      reflect.Value
+}
+
+func collectFields(v reflect.Value, prefix string) []*field {
+	var fields []*field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Tag.Get("yaml") == "-" {
+			continue
+		}
+		path := prefix + sf.Tag.Get("yaml")
+		if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+			fields = append(fields, collectFields(v.Field(i), path+".")...)
+			continue
+		}
+
+		env, opts, _ := strings.Cut(sf.Tag.Get("env"), ",")
+		fields = append(fields, &field{
+			path:       path,
+			env:        env,
+			allowEmpty: opts == "allowempty",
+			secret:     sf.Tag.Get("secret") == "true",
+			reload:     sf.Tag.Get("reload") == "true",
+			value:      v.Field(i),
+		})
+	}
+	return fields
+}
+
+// applyEnv применяет переменную окружения. Пустая переменная не учитывается, если поле
+// не помечено allowempty. Секрет может быть прочитан из файла в переменной <env>_FILE.
+func (f *field) applyEnv() error {
+	value, ok := os.LookupEnv(f.env)
+	if ok && value == "" && !f.allowEmpty {
+		ok = false
+	}
+
+	if f.secret {
+		if path := os.Getenv(f.env + "_FILE"); path != "" {
+			if ok {
+				return fmt.Errorf("%s and %s_FILE are both set", f.env, f.env)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s_FILE: %w", f.env, err)
+			}
+			value, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+	}
+
+	if !ok {
+		return nil
+	}
+	if err := setValue(f.value, value); err != nil {
+		return fmt.Errorf("%s: %w", f.env, err)
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(s)
+	case int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+	case []string:
+		// Список через запятую, пустые элементы пропускаются
+		list := []string{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
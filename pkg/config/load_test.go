@@ -0,0 +1,285 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// clearEnv убирает переменные конфигурации, чтобы окружение машины не влияло на тесты
+func clearEnv(t *testing.T) {
+	t.Helper()
+	unset := func(name string) {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	unset("CONFIG_FILE")
+	for _, f := range collectFields(reflect.ValueOf(Default()).Elem(), "") {
+		unset(f.env)
+		unset(f.env + "_FILE")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := "server:\n  port: 8081\ncache:\n  products_ttl: 1m\n"
+
+	tests := []struct {
+		name     string
+		file     bool
+		env      map[string]string
+		args     []string
+		wantPort int
+		wantTTL  time.Duration
+	}{
+		{name: "defaults", wantPort: 8080, wantTTL: 5 * time.Minute},
+		{name: "file over defaults", file: true, wantPort: 8081, wantTTL: time.Minute},
+		{
+			name:     "env over file",
+			file:     true,
+			env:      map[string]string{"SERVER_PORT": "8082"},
+			wantPort: 8082, wantTTL: time.Minute,
+		},
+		{
+			name:     "flag over env",
+			file:     true,
+			env:      map[string]string{"SERVER_PORT": "8082", "CACHE_PRODUCTS_TTL": "2m"},
+			args:     []string{"-server.port", "8083"},
+			wantPort: 8083, wantTTL: 2 * time.Minute,
+		},
+		{
+			name:     "empty env is ignored",
+			file:     true,
+			env:      map[string]string{"SERVER_PORT": ""},
+			wantPort: 8081, wantTTL: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("AUTH_JWT_SECRET", testSecret)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			args := tt.args
+			if tt.file {
+				args = append([]string{"-config", writeFile(t, "config.yaml", file)}, args...)
+			}
+
+			cfg, err := Load(args, io.Discard)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Port != tt.wantPort {
+				t.Errorf("server.port = %d, want %d", cfg.Server.Port, tt.wantPort)
+			}
+			if cfg.Cache.ProductsTTL != tt.wantTTL {
+				t.Errorf("cache.products_ttl = %v, want %v", cfg.Cache.ProductsTTL, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	passwordFile := writeFile(t, "password", "from-file\n")
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantPassword string
+		wantErr      string
+	}{
+		{name: "env", env: map[string]string{"DB_PASSWORD": "from-env"}, wantPassword: "from-env"},
+		{name: "file, trailing newline trimmed", env: map[string]string{"DB_PASSWORD_FILE": passwordFile}, wantPassword: "from-file"},
+		{
+			name:    "both set",
+			env:     map[string]string{"DB_PASSWORD": "from-env", "DB_PASSWORD_FILE": passwordFile},
+			wantErr: "DB_PASSWORD and DB_PASSWORD_FILE are both set",
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{"DB_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")},
+			wantErr: "DB_PASSWORD_FILE:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("AUTH_JWT_SECRET", testSecret)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(nil, io.Discard)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Database.Password != tt.wantPassword {
+				t.Errorf("database.password = %q, want %q", cfg.Database.Password, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+  trusted_proxies: [10.0.0.0/8]
+database:
+  max_conn_lifetime: 2h
+tracing:
+  sample_ratio: 0.5
+features:
+  swagger_ui: false
+auth:
+  jwt_secret: `+testSecret+`
+`)
+	tomlFile := writeFile(t, "config.toml", `
+[server]
+port = 9000
+trusted_proxies = ["10.0.0.0/8"]
+
+[database]
+max_conn_lifetime = "2h"
+
+[tracing]
+sample_ratio = 0.5
+
+[features]
+swagger_ui = false
+
+[auth]
+jwt_secret = "`+testSecret+`"
+`)
+
+	clearEnv(t)
+	fromYAML, err := Load([]string{"-config", yamlFile}, io.Discard)
+	if err != nil {
+		t.Fatalf("Load yaml: %v", err)
+	}
+	fromTOML, err := Load([]string{"-config", tomlFile}, io.Discard)
+	if err != nil {
+		t.Fatalf("Load toml: %v", err)
+	}
+
+	if fromYAML.Server.Port != 9000 || fromYAML.Database.MaxConnLifetime != 2*time.Hour ||
+		fromYAML.Tracing.SampleRatio != 0.5 || fromYAML.Features.SwaggerUI {
+		t.Errorf("yaml values not applied: %+v", fromYAML)
+	}
+	fromYAML.File, fromTOML.File = "", ""
+	if !reflect.DeepEqual(fromYAML, fromTOML) {
+		t.Errorf("yaml and toml configs differ:\nyaml: %+v\ntoml: %+v", fromYAML, fromTOML)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		env      map[string]string
+		args     []string
+		wantErrs []string
+	}{
+		{
+			name:     "unknown yaml key",
+			file:     "config.yaml",
+			content:  "server:\n  prot: 8081\n",
+			wantErrs: []string{"field prot not found"},
+		},
+		{
+			name:     "unknown toml key",
+			file:     "config.toml",
+			content:  "[server]\nprot = 8081\n",
+			wantErrs: []string{"field prot not found"},
+		},
+		{
+			name:     "unsupported format",
+			file:     "config.json",
+			content:  "{}",
+			wantErrs: []string{"unsupported format"},
+		},
+		{
+			name:     "bad duration in file",
+			file:     "config.yaml",
+			content:  "cache:\n  products_ttl: soon\n",
+			wantErrs: []string{"config file"},
+		},
+		{
+			name: "errors are aggregated",
+			env:  map[string]string{"CACHE_PRODUCTS_TTL": "soon", "SERVER_PORT": "http"},
+			args: []string{"-database.max_conns", "many", "-logging.format", "xml"},
+			wantErrs: []string{
+				`CACHE_PRODUCTS_TTL: invalid duration "soon"`,
+				`SERVER_PORT: invalid integer "http"`,
+				`flag -database.max_conns: invalid integer "many"`,
+				`logging.format: must be one of [json text], got "xml"`,
+			},
+		},
+		{
+			name:     "bad boolean",
+			env:      map[string]string{"FEATURE_SWAGGER_UI": "maybe"},
+			wantErrs: []string{`FEATURE_SWAGGER_UI: invalid boolean "maybe"`},
+		},
+		{
+			name:     "unexpected argument",
+			args:     []string{"serve"},
+			wantErrs: []string{"unexpected arguments: serve"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("AUTH_JWT_SECRET", testSecret)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file, tt.content)}, args...)
+			}
+
+			_, err := Load(args, io.Discard)
+			if err == nil {
+				t.Fatal("Load succeeded, want error")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRequiresJWTSecret(t *testing.T) {
+	clearEnv(t)
+	_, err := Load(nil, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "auth.jwt_secret") {
+		t.Fatalf("Load error = %v, want auth.jwt_secret error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const maskedSecret = "******"
+
+// Print выводит конфигурацию в YAML, пригодном для файла конфигурации. Секреты скрываются.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(toNode(reflect.ValueOf(c).Elem())); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func toNode(v reflect.Value) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Tag.Get("yaml") == "-" {
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: sf.Tag.Get("yaml")}
+
+		var value *yaml.Node
+		fv := v.Field(i)
+		switch x := fv.Interface().(type) {
+		case time.Duration:
+			value = scalar(x.String())
+		case []string:
+			value = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for _, item := range x {
+				if sf.Tag.Get("secret") == "true" {
+					item = maskedSecret
+				}
+				value.Content = append(value.Content, scalar(item))
+			}
+		case string:
+			if sf.Tag.Get("secret") == "true" && x != "" {
+				x = maskedSecret
+			}
+			value = scalar(x)
+			value.Tag = "!!str"
+		case int:
+			value = scalar(strconv.Itoa(x))
+		case bool:
+			value = scalar(strconv.FormatBool(x))
+		case float64:
+			value = scalar(strconv.FormatFloat(x, 'g', -1, 64))
+		default:
+			value = toNode(fv)
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Reloadable возвращает копию c, в которой значения, применяемые без перезапуска, взяты из next.
+// Кроме копии возвращаются пути измененных ключей: примененных и требующих перезапуска.
+func (c *Config) Reloadable(next *Config) (merged *Config, applied, restart []string) {
+	merged = new(Config)
+	*merged = *c
+
+	mergedFields := collectFields(reflect.ValueOf(merged).Elem(), "")
+	nextFields := collectFields(reflect.ValueOf(next).Elem(), "")
+	for i, f := range mergedFields {
+		if reflect.DeepEqual(f.value.Interface(), nextFields[i].value.Interface()) {
+			continue
+		}
+		if !f.reload {
+			restart = append(restart, f.path)
+			continue
+		}
+		f.value.Set(nextFields[i].value)
+		applied = append(applied, f.path)
+	}
+	return merged, applied, restart
+}